@@ -0,0 +1,107 @@
+package lockstitch
+
+import (
+	"crypto/cipher"
+	"errors"
+	"unsafe"
+)
+
+const (
+	// AEADNonceSize is the size, in bytes, of the nonces used by the cipher.AEAD returned by NewAEAD.
+	AEADNonceSize = 16
+
+	// MinAEADKeySize is the minimum size, in bytes, of the keys used by the cipher.AEAD returned by NewAEAD.
+	MinAEADKeySize = 16
+)
+
+// NewAEAD returns a cipher.AEAD which uses the given domain separation string and key. The key must be at least
+// MinAEADKeySize bytes long. Unlike most cipher.AEAD constructors, NewAEAD requires a domain separation string, which
+// ensures that applications which use the same key produce unrelated ciphertexts.
+//
+// Sealing a message is equivalent to initializing a protocol with the domain separation string, mixing the key, nonce,
+// and additional data into the protocol using the labels "key", "nonce", and "ad", and sealing the plaintext using the
+// label "message". The initialized and keyed protocol is computed once and cloned for each message.
+func NewAEAD(domain string, key []byte) (cipher.AEAD, error) {
+	if len(key) < MinAEADKeySize {
+		return nil, errInvalidAEADKey
+	}
+
+	p := NewProtocol(domain)
+	p.Mix("key", key)
+
+	return &aead{p: p}, nil
+}
+
+type aead struct {
+	p *Protocol
+}
+
+func (a *aead) NonceSize() int {
+	return AEADNonceSize
+}
+
+func (a *aead) Overhead() int {
+	return TagLen
+}
+
+func (a *aead) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	p := a.protocol(nonce, additionalData)
+	if invalidOverlap(dst, len(plaintext)+TagLen, plaintext) {
+		panic("lockstitch: invalid buffer overlap")
+	}
+
+	return p.Seal("message", dst, plaintext)
+}
+
+func (a *aead) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	p := a.protocol(nonce, additionalData)
+	if len(ciphertext) < TagLen {
+		return nil, ErrInvalidCiphertext
+	}
+
+	if invalidOverlap(dst, len(ciphertext)-TagLen, ciphertext) {
+		panic("lockstitch: invalid buffer overlap")
+	}
+
+	return p.Open("message", dst, ciphertext)
+}
+
+// protocol returns a clone of the keyed protocol with the nonce and additional data mixed in.
+func (a *aead) protocol(nonce, additionalData []byte) *Protocol {
+	if len(nonce) != AEADNonceSize {
+		panic("lockstitch: incorrect nonce length given to AEAD")
+	}
+
+	p := a.p.Clone()
+	p.Mix("nonce", nonce)
+	p.Mix("ad", additionalData)
+
+	return p
+}
+
+// invalidOverlap returns true if appending n bytes to dst would write to memory which overlaps the input at any
+// non-corresponding index. As with the standard library's AEADs, the output of Seal and Open may only overlap their
+// input if they start at the same address.
+func invalidOverlap(dst []byte, n int, in []byte) bool {
+	// If dst does not have enough capacity, the output will be written to a new slice.
+	if cap(dst)-len(dst) < n {
+		return false
+	}
+
+	return inexactOverlap(dst[len(dst):len(dst)+n], in)
+}
+
+// inexactOverlap returns true if x and y share memory at any non-corresponding index.
+func inexactOverlap(x, y []byte) bool {
+	if len(x) == 0 || len(y) == 0 || &x[0] == &y[0] {
+		return false
+	}
+
+	//nolint:gosec // pointers are only compared, never dereferenced
+	return uintptr(unsafe.Pointer(&x[0])) <= uintptr(unsafe.Pointer(&y[len(y)-1])) &&
+		uintptr(unsafe.Pointer(&y[0])) <= uintptr(unsafe.Pointer(&x[len(x)-1]))
+}
+
+var _ cipher.AEAD = (*aead)(nil)
+
+var errInvalidAEADKey = errors.New("lockstitch: AEAD key must be at least 16 bytes")
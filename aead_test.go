@@ -0,0 +1,362 @@
+package lockstitch_test
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/codahale/lockstitch-go"
+)
+
+func TestNewAEAD(t *testing.T) {
+	t.Parallel()
+
+	if _, err := lockstitch.NewAEAD("com.example.aead", make([]byte, lockstitch.MinAEADKeySize-1)); err == nil {
+		t.Error("NewAEAD(short key) did not return an error")
+	}
+
+	a := newTestAEAD(t)
+	if got, want := a.NonceSize(), lockstitch.AEADNonceSize; got != want {
+		t.Errorf("NonceSize = %d, want = %d", got, want)
+	}
+
+	if got, want := a.Overhead(), lockstitch.TagLen; got != want {
+		t.Errorf("Overhead = %d, want = %d", got, want)
+	}
+}
+
+func TestAEAD_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	a := newTestAEAD(t)
+	nonce := make([]byte, a.NonceSize())
+	prefix := []byte("prefix")
+
+	for _, n := range []int{0, 1, 15, 16, 17, 128, 1024} {
+		plaintext := bytes.Repeat([]byte{0x42}, n)
+		ad := bytes.Repeat([]byte{0x24}, n/2)
+
+		ciphertext := a.Seal(bytes.Clone(prefix), nonce, plaintext, ad)
+		if !bytes.HasPrefix(ciphertext, prefix) {
+			t.Fatalf("Seal did not append to dst: %x", ciphertext)
+		}
+		ciphertext = ciphertext[len(prefix):]
+
+		if got, want := len(ciphertext), n+a.Overhead(); got != want {
+			t.Errorf("len(Seal(%d bytes)) = %d, want = %d", n, got, want)
+		}
+
+		got, err := a.Open(bytes.Clone(prefix), nonce, ciphertext, ad)
+		if err != nil {
+			t.Fatalf("Open(%d bytes) = %v", n, err)
+		}
+
+		if !bytes.Equal(got, append(bytes.Clone(prefix), plaintext...)) {
+			t.Errorf("Open(%d bytes) = %x, want = %x", n, got, plaintext)
+		}
+	}
+}
+
+func TestAEAD_Construction(t *testing.T) {
+	t.Parallel()
+
+	key, nonce, ad := []byte("yellow submarine"), make([]byte, lockstitch.AEADNonceSize), []byte("ad")
+	plaintext := []byte("this is an example")
+
+	p := lockstitch.NewProtocol("com.example.aead")
+	p.Mix("key", key)
+	p.Mix("nonce", nonce)
+	p.Mix("ad", ad)
+	want := p.Seal("message", nil, plaintext)
+
+	if got := newTestAEAD(t).Seal(nil, nonce, plaintext, ad); !bytes.Equal(got, want) {
+		t.Errorf("Seal = %x, want = %x", got, want)
+	}
+}
+
+func TestAEAD_InPlace(t *testing.T) {
+	t.Parallel()
+
+	a := newTestAEAD(t)
+	nonce := make([]byte, a.NonceSize())
+	plaintext := []byte("this is an example")
+
+	buf := make([]byte, len(plaintext), len(plaintext)+a.Overhead())
+	copy(buf, plaintext)
+
+	ciphertext := a.Seal(buf[:0], nonce, buf, nil)
+	if &ciphertext[0] != &buf[0] {
+		t.Error("Seal did not reuse plaintext's storage")
+	}
+
+	got, err := a.Open(ciphertext[:0], nonce, ciphertext, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(got, plaintext) {
+		t.Errorf("Open = %x, want = %x", got, plaintext)
+	}
+}
+
+func TestAEAD_Inauthentic(t *testing.T) {
+	t.Parallel()
+
+	a := newTestAEAD(t)
+	nonce := make([]byte, a.NonceSize())
+	ad := []byte("additional data")
+	ciphertext := a.Seal(nil, nonce, []byte("this is an example"), ad)
+
+	for i := range ciphertext {
+		modified := bytes.Clone(ciphertext)
+		modified[i] ^= 1
+		if _, err := a.Open(nil, nonce, modified, ad); !errors.Is(err, lockstitch.ErrInvalidCiphertext) {
+			t.Errorf("Open(ciphertext with byte %d modified) = %v, want = %v", i, err, lockstitch.ErrInvalidCiphertext)
+		}
+	}
+
+	otherNonce := bytes.Clone(nonce)
+	otherNonce[0] ^= 1
+	if _, err := a.Open(nil, otherNonce, ciphertext, ad); !errors.Is(err, lockstitch.ErrInvalidCiphertext) {
+		t.Errorf("Open(wrong nonce) = %v, want = %v", err, lockstitch.ErrInvalidCiphertext)
+	}
+
+	if _, err := a.Open(nil, nonce, ciphertext, []byte("other data")); !errors.Is(err, lockstitch.ErrInvalidCiphertext) {
+		t.Errorf("Open(wrong ad) = %v, want = %v", err, lockstitch.ErrInvalidCiphertext)
+	}
+
+	if _, err := a.Open(nil, nonce, ciphertext[:lockstitch.TagLen-1], ad); !errors.Is(err, lockstitch.ErrInvalidCiphertext) {
+		t.Errorf("Open(short ciphertext) = %v, want = %v", err, lockstitch.ErrInvalidCiphertext)
+	}
+
+	other, err := lockstitch.NewAEAD("com.example.aead", []byte("another sixteen byte key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := other.Open(nil, nonce, ciphertext, ad); !errors.Is(err, lockstitch.ErrInvalidCiphertext) {
+		t.Errorf("Open(wrong key) = %v, want = %v", err, lockstitch.ErrInvalidCiphertext)
+	}
+}
+
+func TestAEAD_InauthenticClearsOutput(t *testing.T) {
+	t.Parallel()
+
+	a := newTestAEAD(t)
+	nonce := make([]byte, a.NonceSize())
+	ciphertext := a.Seal(nil, nonce, []byte("this is an example"), nil)
+	ciphertext[0] ^= 1
+
+	dst := make([]byte, 0, len(ciphertext))
+	if _, err := a.Open(dst, nonce, ciphertext, nil); err == nil {
+		t.Fatal("Open(modified ciphertext) did not return an error")
+	}
+
+	if out := dst[:len(ciphertext)-a.Overhead()]; !bytes.Equal(out, make([]byte, len(out))) {
+		t.Errorf("Open left unauthenticated plaintext in dst: %x", out)
+	}
+}
+
+func TestAEAD_InvalidNonce(t *testing.T) {
+	t.Parallel()
+
+	a := newTestAEAD(t)
+	for _, f := range []func(){
+		func() { a.Seal(nil, make([]byte, a.NonceSize()-1), nil, nil) },
+		func() { _, _ = a.Open(nil, make([]byte, a.NonceSize()+1), make([]byte, a.Overhead()), nil) },
+	} {
+		func() {
+			defer func() {
+				if r := recover(); r == nil {
+					t.Errorf("The code did not panic")
+				}
+			}()
+			f()
+		}()
+	}
+}
+
+func TestAEAD_Conformance(t *testing.T) {
+	t.Parallel()
+
+	t.Run("lockstitch", func(t *testing.T) {
+		t.Parallel()
+
+		testAEAD(t, newTestAEAD(t))
+	})
+
+	// Ensure the conformance tests match the behavior of the standard library's AES-GCM.
+	t.Run("AES-GCM", func(t *testing.T) {
+		t.Parallel()
+
+		block, err := aes.NewCipher([]byte("yellow submarine"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		gcm, err := cipher.NewGCM(block)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		testAEAD(t, gcm)
+	})
+}
+
+// testAEAD checks that a cipher.AEAD behaves as required by the cipher.AEAD interface, following the standard library's
+// AEAD conformance tests.
+//
+//nolint:funlen // it's a long list of checks
+func testAEAD(t *testing.T, a cipher.AEAD) {
+	t.Helper()
+
+	nonce := bytes.Repeat([]byte{0x11}, a.NonceSize())
+	prefix := []byte("prefix")
+
+	t.Run("RoundTrip", func(t *testing.T) {
+		for _, n := range []int{0, 1, 15, 16, 17, 31, 32, 33, 255, 1024} {
+			for _, adLen := range []int{0, 1, 16, 33} {
+				plaintext, ad := bytes.Repeat([]byte{0x42}, n), bytes.Repeat([]byte{0x24}, adLen)
+
+				ciphertext := a.Seal(bytes.Clone(prefix), nonce, plaintext, ad)
+				if !bytes.HasPrefix(ciphertext, prefix) {
+					t.Fatalf("Seal(%d, %d) did not append to dst", n, adLen)
+				}
+				ciphertext = ciphertext[len(prefix):]
+
+				if got, want := len(ciphertext), n+a.Overhead(); got != want {
+					t.Errorf("len(Seal(%d, %d)) = %d, want = %d", n, adLen, got, want)
+				}
+
+				if got, want := a.Seal(nil, nonce, plaintext, ad), ciphertext; !bytes.Equal(got, want) {
+					t.Errorf("Seal(%d, %d) is not deterministic: %x != %x", n, adLen, got, want)
+				}
+
+				got, err := a.Open(bytes.Clone(prefix), nonce, ciphertext, ad)
+				if err != nil {
+					t.Fatalf("Open(%d, %d) = %v", n, adLen, err)
+				}
+
+				if want := append(bytes.Clone(prefix), plaintext...); !bytes.Equal(got, want) {
+					t.Errorf("Open(%d, %d) = %x, want = %x", n, adLen, got, want)
+				}
+			}
+		}
+	})
+
+	t.Run("InputNotModified", func(t *testing.T) {
+		plaintext, ad := []byte("this is an example"), []byte("additional data")
+		nonceC, plaintextC, adC := bytes.Clone(nonce), bytes.Clone(plaintext), bytes.Clone(ad)
+
+		ciphertext := a.Seal(nil, nonce, plaintext, ad)
+		if !bytes.Equal(nonce, nonceC) || !bytes.Equal(plaintext, plaintextC) || !bytes.Equal(ad, adC) {
+			t.Error("Seal modified its inputs")
+		}
+
+		ciphertextC := bytes.Clone(ciphertext)
+		if _, err := a.Open(nil, nonce, ciphertext, ad); err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(nonce, nonceC) || !bytes.Equal(ciphertext, ciphertextC) || !bytes.Equal(ad, adC) {
+			t.Error("Open modified its inputs")
+		}
+	})
+
+	t.Run("InPlace", func(t *testing.T) {
+		plaintext := []byte("this is an example")
+		want := a.Seal(nil, nonce, plaintext, nil)
+
+		buf := make([]byte, len(plaintext), len(plaintext)+a.Overhead())
+		copy(buf, plaintext)
+		ciphertext := a.Seal(buf[:0], nonce, buf, nil)
+		if !bytes.Equal(ciphertext, want) || &ciphertext[0] != &buf[0] {
+			t.Errorf("Seal(in place) = %x, want = %x", ciphertext, want)
+		}
+
+		got, err := a.Open(ciphertext[:0], nonce, ciphertext, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(got, plaintext) || &got[0] != &buf[0] {
+			t.Errorf("Open(in place) = %x, want = %x", got, plaintext)
+		}
+	})
+
+	t.Run("BufferOverlap", func(t *testing.T) {
+		const n = 32
+		for _, offset := range []int{-2, -1, 1, 2} {
+			buf := make([]byte, 2*(n+a.Overhead())+4)
+			in, out := buf[2:2+n], buf[2+offset:2+offset]
+
+			assertPanics(t, fmt.Sprintf("Seal(offset %d)", offset), func() {
+				a.Seal(out, nonce, in, nil)
+			})
+
+			ciphertext := a.Seal(nil, nonce, make([]byte, n), nil)
+			in = buf[2 : 2+len(ciphertext)]
+			copy(in, ciphertext)
+			assertPanics(t, fmt.Sprintf("Open(offset %d)", offset), func() {
+				_, _ = a.Open(out, nonce, in, nil)
+			})
+		}
+	})
+
+	t.Run("WrongNonceLength", func(t *testing.T) {
+		ciphertext := a.Seal(nil, nonce, []byte("this is an example"), nil)
+		for _, n := range []int{0, a.NonceSize() - 1, a.NonceSize() + 1} {
+			assertPanics(t, fmt.Sprintf("Seal(%d-byte nonce)", n), func() {
+				a.Seal(nil, make([]byte, n), nil, nil)
+			})
+			assertPanics(t, fmt.Sprintf("Open(%d-byte nonce)", n), func() {
+				_, _ = a.Open(nil, make([]byte, n), ciphertext, nil)
+			})
+		}
+	})
+
+	t.Run("Inauthentic", func(t *testing.T) {
+		ad := []byte("additional data")
+		ciphertext := a.Seal(nil, nonce, []byte("this is an example"), ad)
+
+		otherNonce := bytes.Clone(nonce)
+		otherNonce[0] ^= 1
+		modified := bytes.Clone(ciphertext)
+		modified[0] ^= 1
+
+		for name, f := range map[string]func() ([]byte, error){
+			"wrong nonce":      func() ([]byte, error) { return a.Open(nil, otherNonce, ciphertext, ad) },
+			"wrong ad":         func() ([]byte, error) { return a.Open(nil, nonce, ciphertext, ad[1:]) },
+			"wrong ciphertext": func() ([]byte, error) { return a.Open(nil, nonce, modified, ad) },
+			"short ciphertext": func() ([]byte, error) { return a.Open(nil, nonce, ciphertext[:a.Overhead()-1], ad) },
+		} {
+			if out, err := f(); err == nil || out != nil {
+				t.Errorf("Open(%s) = %x, %v, want = nil, error", name, out, err)
+			}
+		}
+	})
+}
+
+func assertPanics(tb testing.TB, name string, f func()) {
+	tb.Helper()
+
+	defer func() {
+		if r := recover(); r == nil {
+			tb.Errorf("%s did not panic", name)
+		}
+	}()
+	f()
+}
+
+func newTestAEAD(tb testing.TB) cipher.AEAD {
+	tb.Helper()
+
+	a, err := lockstitch.NewAEAD("com.example.aead", []byte("yellow submarine"))
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	return a
+}
@@ -0,0 +1,275 @@
+// Package balloon implements memory-hard password hashing using Balloon hashing with Lockstitch as the compression
+// function.
+//
+// Balloon hashing fills a buffer of blocks with pseudorandom data derived from the password and salt, then mixes each
+// block with its predecessor and with pseudorandomly selected other blocks for a number of rounds. Each invocation of
+// the compression function clones a protocol which already has the password, salt, and parameters mixed into it, mixes
+// in a counter and the input blocks, and derives the output block.
+//
+// The indexes of the pseudorandomly selected blocks are derived from a separate protocol which has only the salt,
+// parameters, and lane index mixed into it. The memory access pattern is therefore independent of the password, which
+// prevents cache-timing attacks from leaking information about it.
+//
+// The buffer is split between one or more independent lanes, which are computed in parallel. The final hash is derived
+// from the final block of each lane.
+//
+// Password hashes are encoded as PHC-style strings:
+//
+//	$lockstitch-balloon$m=<memory>,t=<time>,p=<parallelism>$<salt>$<hash>
+//
+// where the salt and hash are encoded using unpadded standard base64. Verify and NeedsRehash reject encoded password
+// hashes whose parameters exceed MaxMemory or MaxTime, which bounds both the memory and the time a maliciously crafted
+// hash can consume.
+package balloon
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/codahale/lockstitch-go"
+)
+
+const (
+	// SaltLen is the length, in bytes, of the salts generated by Hash.
+	SaltLen = 16
+
+	// HashLen is the length, in bytes, of the hashes generated by Hash.
+	HashLen = 32
+
+	// BlockSize is the size, in bytes, of a buffer block.
+	BlockSize = 1024
+
+	// MaxMemory is the maximum size, in KiB, of the buffer. Encoded password hashes with larger Memory parameters are
+	// rejected by Verify and NeedsRehash, so a maliciously crafted hash cannot cause excessive memory allocation.
+	MaxMemory = 1024 * 1024
+
+	// MaxTime is the maximum number of rounds of mixing. Encoded password hashes with larger Time parameters are
+	// rejected by Verify and NeedsRehash, so a maliciously crafted hash cannot cause excessive computation.
+	MaxTime = 256
+)
+
+// Params are the cost parameters of Balloon hashing.
+type Params struct {
+	// Memory is the size of the buffer, in KiB. It must be at least 8 KiB per lane and at most MaxMemory.
+	Memory uint32
+
+	// Time is the number of rounds of mixing. It must be at least 1 and at most MaxTime.
+	Time uint32
+
+	// Parallelism is the number of lanes, each of which uses Memory/Parallelism KiB. It must be at least 1.
+	Parallelism uint8
+}
+
+// DefaultParams are the recommended parameters for interactive logins. They may be increased in future versions.
+//
+//nolint:gochecknoglobals // this is a constant
+var DefaultParams = Params{Memory: 16 * 1024, Time: 3, Parallelism: 2}
+
+var (
+	// ErrInvalidParams is returned when the cost parameters are invalid.
+	ErrInvalidParams = errors.New("balloon: invalid parameters")
+
+	// ErrInvalidHash is returned when an encoded password hash is invalid.
+	ErrInvalidHash = errors.New("balloon: invalid hash")
+
+	// ErrMismatchedHashAndPassword is returned when a password does not match an encoded password hash.
+	ErrMismatchedHashAndPassword = errors.New("balloon: hash is not the hash of the given password")
+)
+
+// Hash hashes the password with a random salt using the given parameters and returns the encoded password hash.
+func Hash(password []byte, params Params) (string, error) {
+	if err := params.validate(); err != nil {
+		return "", err
+	}
+
+	salt := make([]byte, SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	return encode(params, salt, Key(password, salt, params, HashLen)), nil
+}
+
+// Verify returns nil if the password matches the encoded password hash. If it does not, Verify returns
+// ErrMismatchedHashAndPassword.
+func Verify(encoded string, password []byte) error {
+	params, salt, hash, err := decode(encoded)
+	if err != nil {
+		return err
+	}
+
+	if subtle.ConstantTimeCompare(Key(password, salt, params, len(hash)), hash) != 1 {
+		return ErrMismatchedHashAndPassword
+	}
+
+	return nil
+}
+
+// NeedsRehash returns true if the encoded password hash was created with parameters, a salt length, or a hash length
+// other than those Hash would use with the given parameters. A password which has been verified should be rehashed
+// and the new hash stored if NeedsRehash returns true.
+func NeedsRehash(encoded string, params Params) (bool, error) {
+	p, salt, hash, err := decode(encoded)
+	if err != nil {
+		return false, err
+	}
+
+	return p != params || len(salt) != SaltLen || len(hash) != HashLen, nil
+}
+
+// Key derives a key of length keyLen from the password and salt using the given parameters. It panics if the
+// parameters are invalid.
+func Key(password, salt []byte, params Params, keyLen int) []byte {
+	if err := params.validate(); err != nil {
+		panic(err)
+	}
+
+	base := lockstitch.NewProtocol("lockstitch-go.balloon")
+	base.Mix("password", password)
+	base.Mix("salt", salt)
+	base.Mix("params", params.appendBinary(nil))
+
+	// The indexes of the pseudorandomly selected blocks depend only on the salt and parameters, not the password.
+	index := lockstitch.NewProtocol("lockstitch-go.balloon.index")
+	index.Mix("salt", salt)
+	index.Mix("params", params.appendBinary(nil))
+
+	// Fill and mix each lane in parallel.
+	lanes := make([][]byte, params.Parallelism)
+	blocks := uint64(params.Memory) * 1024 / BlockSize / uint64(params.Parallelism)
+
+	var wg sync.WaitGroup
+	for i := range lanes {
+		wg.Go(func() {
+			laneBase, laneIndex := base.Clone(), index.Clone()
+			laneBase.Mix("lane", binary.BigEndian.AppendUint64(nil, uint64(i)))
+			laneIndex.Mix("lane", binary.BigEndian.AppendUint64(nil, uint64(i)))
+			lanes[i] = lane(laneBase, laneIndex, blocks, params.Time)
+		})
+	}
+	wg.Wait()
+
+	// Derive the key from the final block of each lane.
+	for _, out := range lanes {
+		base.Mix("lane output", out)
+	}
+
+	return base.Derive("key", nil, keyLen)
+}
+
+// lane fills a buffer of the given number of blocks, mixes it for the given number of rounds, and returns its final
+// block. The indexes of the pseudorandomly selected blocks are derived from clones of the index protocol.
+func lane(base, index *lockstitch.Protocol, blocks uint64, rounds uint32) []byte {
+	buf := make([]byte, blocks*BlockSize)
+	block := func(i uint64) []byte {
+		return buf[i*BlockSize : (i+1)*BlockSize]
+	}
+
+	var counter uint64
+	var counterBuf, idx [8]byte
+	var idxInput [24]byte
+
+	// h clones the base protocol, mixes in the counter and the inputs, and derives len(dst) bytes into dst.
+	h := func(dst []byte, inputs ...[]byte) {
+		p := base.Clone()
+		p.Mix("counter", binary.BigEndian.AppendUint64(counterBuf[:0], counter))
+		counter++
+		for _, in := range inputs {
+			p.Mix("input", in)
+		}
+		p.Derive("output", dst[:0], len(dst))
+	}
+
+	// Expand the input into the buffer.
+	h(block(0))
+	for m := uint64(1); m < blocks; m++ {
+		h(block(m), block(m-1))
+	}
+
+	// Mix the buffer.
+	for t := range uint64(rounds) {
+		for m := range blocks {
+			// Mix the previous block into the current block.
+			h(block(m), block((m+blocks-1)%blocks), block(m))
+
+			// Mix pseudorandomly selected blocks into the current block.
+			for i := range uint64(delta) {
+				binary.BigEndian.PutUint64(idxInput[0:], t)
+				binary.BigEndian.PutUint64(idxInput[8:], m)
+				binary.BigEndian.PutUint64(idxInput[16:], i)
+				p := index.Clone()
+				p.Mix("index", idxInput[:])
+				p.Derive("index", idx[:0], len(idx))
+				other := binary.BigEndian.Uint64(idx[:]) % blocks
+
+				h(block(m), block(m), block(other))
+			}
+		}
+	}
+
+	return block(blocks - 1)
+}
+
+func (p Params) validate() error {
+	if p.Time < 1 || p.Time > MaxTime || p.Parallelism < 1 || p.Memory < minLaneMemory*uint32(p.Parallelism) ||
+		p.Memory > MaxMemory {
+		return ErrInvalidParams
+	}
+
+	return nil
+}
+
+func (p Params) appendBinary(b []byte) []byte {
+	b = binary.BigEndian.AppendUint32(b, p.Memory)
+	b = binary.BigEndian.AppendUint32(b, p.Time)
+	return append(b, p.Parallelism)
+}
+
+func encode(params Params, salt, hash []byte) string {
+	return fmt.Sprintf("$%s$m=%d,t=%d,p=%d$%s$%s", algorithmID, params.Memory, params.Time, params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(hash))
+}
+
+func decode(encoded string) (params Params, salt, hash []byte, err error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 5 || parts[0] != "" || parts[1] != algorithmID {
+		return params, nil, nil, ErrInvalidHash
+	}
+
+	if _, err := fmt.Sscanf(parts[2], "m=%d,t=%d,p=%d", &params.Memory, &params.Time, &params.Parallelism); err != nil {
+		return params, nil, nil, ErrInvalidHash
+	}
+
+	// Reject non-canonical parameter encodings.
+	if parts[2] != fmt.Sprintf("m=%d,t=%d,p=%d", params.Memory, params.Time, params.Parallelism) {
+		return params, nil, nil, ErrInvalidHash
+	}
+
+	if err := params.validate(); err != nil {
+		return params, nil, nil, ErrInvalidHash
+	}
+
+	salt, err = base64.RawStdEncoding.Strict().DecodeString(parts[3])
+	if err != nil {
+		return params, nil, nil, ErrInvalidHash
+	}
+
+	hash, err = base64.RawStdEncoding.Strict().DecodeString(parts[4])
+	if err != nil || len(hash) == 0 {
+		return params, nil, nil, ErrInvalidHash
+	}
+
+	return params, salt, hash, nil
+}
+
+const (
+	algorithmID   = "lockstitch-balloon" // The PHC algorithm identifier.
+	delta         = 3                    // The number of pseudorandomly selected blocks mixed into each block.
+	minLaneMemory = 8                    // The minimum memory, in KiB, of each lane.
+)
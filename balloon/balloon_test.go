@@ -0,0 +1,138 @@
+package balloon_test
+
+import (
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/codahale/lockstitch-go/balloon"
+)
+
+//nolint:gochecknoglobals // test parameters
+var testParams = balloon.Params{Memory: 64, Time: 2, Parallelism: 2}
+
+func TestKey(t *testing.T) {
+	t.Parallel()
+
+	key := balloon.Key([]byte("password"), []byte("salt"), testParams, 16)
+	if got, want := hex.EncodeToString(key), "fe36bc3d68f27904cb659d22139f858d"; got != want {
+		t.Errorf("Key = %s, want = %s", got, want)
+	}
+
+	for _, params := range []balloon.Params{
+		{Memory: 64, Time: 1, Parallelism: 2},
+		{Memory: 128, Time: 2, Parallelism: 2},
+		{Memory: 64, Time: 2, Parallelism: 1},
+	} {
+		if other := balloon.Key([]byte("password"), []byte("salt"), params, 16); hex.EncodeToString(other) == hex.EncodeToString(key) {
+			t.Errorf("Key(%+v) = Key(%+v)", params, testParams)
+		}
+	}
+}
+
+func TestHash(t *testing.T) {
+	t.Parallel()
+
+	encoded, err := balloon.Hash([]byte("password"), testParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(encoded, "$lockstitch-balloon$m=64,t=2,p=2$") {
+		t.Errorf("Hash = %q, want $lockstitch-balloon$m=64,t=2,p=2$ prefix", encoded)
+	}
+
+	if err := balloon.Verify(encoded, []byte("password")); err != nil {
+		t.Errorf("Verify = %v, want = nil", err)
+	}
+
+	if err := balloon.Verify(encoded, []byte("wrong password")); !errors.Is(err, balloon.ErrMismatchedHashAndPassword) {
+		t.Errorf("Verify(wrong password) = %v, want = %v", err, balloon.ErrMismatchedHashAndPassword)
+	}
+
+	other, err := balloon.Hash([]byte("password"), testParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if encoded == other {
+		t.Error("Hash produced identical hashes for the same password")
+	}
+}
+
+func TestVerify_Vector(t *testing.T) {
+	t.Parallel()
+
+	const encoded = "$lockstitch-balloon$m=64,t=2,p=2$MDEyMzQ1Njc4OWFiY2RlZg$J/U5TNPN8XxVolw0nlytJlV4THL2WQK9K6aRCCIgVRc"
+
+	if err := balloon.Verify(encoded, []byte("password")); err != nil {
+		t.Errorf("Verify = %v, want = nil", err)
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	t.Parallel()
+
+	encoded, err := balloon.Hash([]byte("password"), testParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		params balloon.Params
+		want   bool
+	}{
+		{testParams, false},
+		{balloon.Params{Memory: 128, Time: 2, Parallelism: 2}, true},
+		{balloon.Params{Memory: 64, Time: 3, Parallelism: 2}, true},
+		{balloon.Params{Memory: 64, Time: 2, Parallelism: 1}, true},
+	} {
+		got, err := balloon.NeedsRehash(encoded, tc.params)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got != tc.want {
+			t.Errorf("NeedsRehash(%+v) = %v, want = %v", tc.params, got, tc.want)
+		}
+	}
+}
+
+func TestVerify_InvalidHash(t *testing.T) {
+	t.Parallel()
+
+	for _, encoded := range []string{
+		"",
+		"$argon2id$v=19$m=65536,t=3,p=4$c2FsdA$aGFzaA",
+		"$lockstitch-balloon$m=64,t=2$c2FsdA$aGFzaA",
+		"$lockstitch-balloon$m=064,t=2,p=2$c2FsdA$aGFzaA",
+		"$lockstitch-balloon$m=64,t=0,p=2$c2FsdA$aGFzaA",
+		"$lockstitch-balloon$m=8,t=2,p=2$c2FsdA$aGFzaA",
+		"$lockstitch-balloon$m=4294967295,t=2,p=2$c2FsdA$aGFzaA",
+		"$lockstitch-balloon$m=64,t=4294967295,p=2$c2FsdA$aGFzaA",
+		"$lockstitch-balloon$m=64,t=2,p=2$c2FsdA==$aGFzaA",
+		"$lockstitch-balloon$m=64,t=2,p=2$c2FsdA$",
+		"$lockstitch-balloon$m=64,t=2,p=2$c2FsdA$aGFzaA$",
+	} {
+		if err := balloon.Verify(encoded, []byte("password")); !errors.Is(err, balloon.ErrInvalidHash) {
+			t.Errorf("Verify(%q) = %v, want = %v", encoded, err, balloon.ErrInvalidHash)
+		}
+	}
+}
+
+func TestHash_InvalidParams(t *testing.T) {
+	t.Parallel()
+
+	for _, params := range []balloon.Params{
+		{Memory: 64, Time: 0, Parallelism: 1},
+		{Memory: 64, Time: 1, Parallelism: 0},
+		{Memory: 8, Time: 1, Parallelism: 2},
+		{Memory: balloon.MaxMemory + 1, Time: 1, Parallelism: 1},
+		{Memory: 64, Time: balloon.MaxTime + 1, Parallelism: 1},
+	} {
+		if _, err := balloon.Hash([]byte("password"), params); !errors.Is(err, balloon.ErrInvalidParams) {
+			t.Errorf("Hash(%+v) = %v, want = %v", params, err, balloon.ErrInvalidParams)
+		}
+	}
+}
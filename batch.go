@@ -0,0 +1,166 @@
+package lockstitch
+
+import (
+	"crypto/sha256"
+	"encoding"
+	"hash"
+)
+
+// A BatchMessage is a single message sealed by SealBatch or opened by OpenBatch.
+type BatchMessage struct {
+	// Nonce is the message's nonce.
+	Nonce []byte
+
+	// Data is the message's plaintext, when sealing, or its ciphertext, when opening.
+	Data []byte
+}
+
+// SealBatch seals each message's plaintext using a clone of the base protocol with the message's nonce mixed in, and
+// returns the resulting ciphertexts. It is equivalent to the following, but amortizes the cost of cloning the base
+// protocol and allocating the ciphertexts across all the messages:
+//
+//	for i, m := range messages {
+//		p := base.Clone()
+//		p.Mix("nonce", m.Nonce)
+//		ciphertexts[i] = p.Seal(label, nil, m.Data)
+//	}
+//
+// The base protocol is not modified, but must not be used concurrently with SealBatch.
+func SealBatch(base *Protocol, label string, messages []BatchMessage) [][]byte {
+	p, state := base.batch()
+
+	n := 0
+	for _, m := range messages {
+		n += len(m.Data) + TagLen
+	}
+
+	// Allocate a single buffer for all the ciphertexts.
+	buf := make([]byte, 0, n)
+	ciphertexts := make([][]byte, len(messages))
+	for i, m := range messages {
+		p.reset(state)
+		p.Mix("nonce", m.Nonce)
+		start := len(buf)
+		buf = p.Seal(label, buf, m.Data)
+		ciphertexts[i] = buf[start:len(buf):len(buf)]
+	}
+
+	return ciphertexts
+}
+
+// OpenBatch opens each message's ciphertext using a clone of the base protocol with the message's nonce mixed in, and
+// returns the resulting plaintexts. It is equivalent to the following, but amortizes the cost of cloning the base
+// protocol and allocating the plaintexts across all the messages:
+//
+//	for i, m := range messages {
+//		p := base.Clone()
+//		p.Mix("nonce", m.Nonce)
+//		plaintexts[i], err = p.Open(label, nil, m.Data)
+//	}
+//
+// If any of the ciphertexts are not authentic, OpenBatch returns ErrInvalidCiphertext along with the plaintexts, in
+// which the plaintexts of the inauthentic ciphertexts are nil. The base protocol is not modified, but must not be used
+// concurrently with OpenBatch.
+func OpenBatch(base *Protocol, label string, messages []BatchMessage) ([][]byte, error) {
+	p, state := base.batch()
+
+	n := 0
+	for _, m := range messages {
+		n += max(len(m.Data)-TagLen, 0)
+	}
+
+	// Allocate a single buffer for all the plaintexts.
+	buf := make([]byte, 0, n)
+	plaintexts := make([][]byte, len(messages))
+
+	var err error
+	for i, m := range messages {
+		if len(m.Data) < TagLen {
+			err = ErrInvalidCiphertext
+			continue
+		}
+
+		p.reset(state)
+		p.Mix("nonce", m.Nonce)
+		start := len(buf)
+		out, openErr := p.Open(label, buf, m.Data)
+		if openErr != nil {
+			err = openErr
+			continue
+		}
+
+		buf = out
+		plaintexts[i] = buf[start:len(buf):len(buf)]
+	}
+
+	return plaintexts, err
+}
+
+// batch returns the protocol's transcript state and a new protocol which can be repeatedly reset to that state without
+// allocating.
+func (p *Protocol) batch() (*Protocol, []byte) {
+	state, err := p.MarshalBinary()
+	if err != nil {
+		panic(err)
+	}
+
+	//nolint:exhaustruct // noCopy should not be initialized
+	return &Protocol{
+		transcript: &batchTranscript{
+			Hash:    sha256.New(),
+			scratch: sha256.New(),
+			state:   make([]byte, 0, len(state)),
+		},
+		buf: make([]byte, initialBufLen),
+		rec: nil,
+	}, state
+}
+
+// reset replaces the protocol's transcript state with the given state.
+func (p *Protocol) reset(state []byte) {
+	if err := p.transcript.(encoding.BinaryUnmarshaler).UnmarshalBinary(state); err != nil { //nolint:errcheck // cannot panic
+		panic(err)
+	}
+}
+
+// A batchTranscript is a transcript which reuses a scratch transcript instead of allocating a new one when cloned. This
+// makes the protocol's expand operation allocation-free, but means that the transcript returned by Clone is only valid
+// until the next call to Clone. It must only be used by protocols which are never cloned.
+type batchTranscript struct {
+	hash.Hash
+
+	scratch hash.Hash // The transcript returned by Clone.
+	state   []byte    // A reusable buffer for the transcript's state.
+}
+
+// Clone copies the transcript's state to the scratch transcript and returns it.
+func (t *batchTranscript) Clone() (hash.Cloner, error) {
+	state, err := t.AppendBinary(t.state[:0])
+	if err != nil {
+		return nil, err
+	}
+	t.state = state
+
+	if err := t.scratch.(encoding.BinaryUnmarshaler).UnmarshalBinary(state); err != nil { //nolint:errcheck // cannot panic
+		return nil, err
+	}
+
+	return t.scratch.(hash.Cloner), nil //nolint:errcheck // cannot panic
+}
+
+// AppendBinary appends the transcript's state to b.
+func (t *batchTranscript) AppendBinary(b []byte) ([]byte, error) {
+	return t.Hash.(encoding.BinaryAppender).AppendBinary(b) //nolint:errcheck // cannot panic
+}
+
+// MarshalBinary returns the transcript's state.
+func (t *batchTranscript) MarshalBinary() ([]byte, error) {
+	return t.Hash.(encoding.BinaryMarshaler).MarshalBinary() //nolint:errcheck // cannot panic
+}
+
+// UnmarshalBinary replaces the transcript's state.
+func (t *batchTranscript) UnmarshalBinary(data []byte) error {
+	return t.Hash.(encoding.BinaryUnmarshaler).UnmarshalBinary(data) //nolint:errcheck // cannot panic
+}
+
+var _ hash.Cloner = (*batchTranscript)(nil)
@@ -0,0 +1,93 @@
+package lockstitch_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/codahale/lockstitch-go"
+)
+
+func TestSealBatch(t *testing.T) {
+	t.Parallel()
+
+	base := lockstitch.NewProtocol("com.example.batch")
+	base.Mix("key", []byte("yellow submarine"))
+
+	messages := make([]lockstitch.BatchMessage, 10)
+	for i := range messages {
+		messages[i] = lockstitch.BatchMessage{
+			Nonce: fmt.Appendf(nil, "nonce %d", i),
+			Data:  bytes.Repeat([]byte{byte(i)}, i*10),
+		}
+	}
+
+	ciphertexts := lockstitch.SealBatch(base, "message", messages)
+
+	if got, want := len(ciphertexts), len(messages); got != want {
+		t.Fatalf("len(SealBatch) = %d, want = %d", got, want)
+	}
+
+	for i, m := range messages {
+		p := base.Clone()
+		p.Mix("nonce", m.Nonce)
+		if got, want := ciphertexts[i], p.Seal("message", nil, m.Data); !bytes.Equal(got, want) {
+			t.Errorf("SealBatch[%d] = %x, want = %x", i, got, want)
+		}
+	}
+
+	opened := make([]lockstitch.BatchMessage, len(messages))
+	for i, m := range messages {
+		opened[i] = lockstitch.BatchMessage{Nonce: m.Nonce, Data: ciphertexts[i]}
+	}
+
+	plaintexts, err := lockstitch.OpenBatch(base, "message", opened)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, m := range messages {
+		if got, want := plaintexts[i], m.Data; !bytes.Equal(got, want) {
+			t.Errorf("OpenBatch[%d] = %x, want = %x", i, got, want)
+		}
+	}
+
+	// The base protocol is not modified.
+	p := lockstitch.NewProtocol("com.example.batch")
+	p.Mix("key", []byte("yellow submarine"))
+	if got, want := base.Derive("state", nil, 8), p.Derive("state", nil, 8); !bytes.Equal(got, want) {
+		t.Errorf("Derive = %x, want = %x", got, want)
+	}
+}
+
+func TestOpenBatch_Inauthentic(t *testing.T) {
+	t.Parallel()
+
+	base := lockstitch.NewProtocol("com.example.batch")
+	base.Mix("key", []byte("yellow submarine"))
+
+	messages := []lockstitch.BatchMessage{
+		{Nonce: []byte("one"), Data: []byte("first message")},
+		{Nonce: []byte("two"), Data: []byte("second message")},
+		{Nonce: []byte("three"), Data: []byte("third message")},
+	}
+	ciphertexts := lockstitch.SealBatch(base, "message", messages)
+
+	ciphertexts[1][0] ^= 1
+	plaintexts, err := lockstitch.OpenBatch(base, "message", []lockstitch.BatchMessage{
+		{Nonce: []byte("one"), Data: ciphertexts[0]},
+		{Nonce: []byte("two"), Data: ciphertexts[1]},
+		{Nonce: []byte("three"), Data: ciphertexts[2]},
+		{Nonce: []byte("four"), Data: []byte("short")},
+	})
+	if !errors.Is(err, lockstitch.ErrInvalidCiphertext) {
+		t.Errorf("OpenBatch = %v, want = %v", err, lockstitch.ErrInvalidCiphertext)
+	}
+
+	for i, want := range [][]byte{[]byte("first message"), nil, []byte("third message"), nil} {
+		if got := plaintexts[i]; !bytes.Equal(got, want) || (want == nil) != (got == nil) {
+			t.Errorf("OpenBatch[%d] = %q, want = %q", i, got, want)
+		}
+	}
+}
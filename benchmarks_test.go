@@ -167,6 +167,118 @@ func BenchmarkAEAD(b *testing.B) {
 	}
 }
 
+func BenchmarkTemplate(b *testing.B) {
+	base := lockstitch.NewProtocol("template")
+	base.Mix("key", make([]byte, 32))
+	tmpl := lockstitch.NewTemplate(base)
+
+	b.Run("clone", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			base.Clone()
+		}
+	})
+
+	b.Run("new", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			tmpl.New()
+		}
+	})
+
+	b.Run("reset", func(b *testing.B) {
+		p := tmpl.New()
+		b.ReportAllocs()
+		for b.Loop() {
+			tmpl.Reset(p)
+		}
+	})
+}
+
+func BenchmarkSealBatch(b *testing.B) {
+	key := make([]byte, 32)
+	messages := make([]lockstitch.BatchMessage, 1000)
+	for i := range messages {
+		messages[i] = lockstitch.BatchMessage{Nonce: make([]byte, 16), Data: make([]byte, 128)}
+	}
+
+	base := lockstitch.NewProtocol("batch")
+	base.Mix("key", key)
+
+	b.Run("loop", func(b *testing.B) {
+		b.ReportAllocs()
+		b.SetBytes(int64(len(messages) * 128))
+		for b.Loop() {
+			for _, m := range messages {
+				protocol := lockstitch.NewProtocol("batch")
+				protocol.Mix("key", key)
+				protocol.Mix("nonce", m.Nonce)
+				protocol.Seal("message", nil, m.Data)
+			}
+		}
+	})
+
+	b.Run("clone", func(b *testing.B) {
+		b.ReportAllocs()
+		b.SetBytes(int64(len(messages) * 128))
+		for b.Loop() {
+			for _, m := range messages {
+				protocol := base.Clone()
+				protocol.Mix("nonce", m.Nonce)
+				protocol.Seal("message", nil, m.Data)
+			}
+		}
+	})
+
+	b.Run("batch", func(b *testing.B) {
+		b.ReportAllocs()
+		b.SetBytes(int64(len(messages) * 128))
+		for b.Loop() {
+			lockstitch.SealBatch(base, "message", messages)
+		}
+	})
+}
+
+func BenchmarkOpenBatch(b *testing.B) {
+	key := make([]byte, 32)
+	plaintexts := make([]lockstitch.BatchMessage, 1000)
+	for i := range plaintexts {
+		plaintexts[i] = lockstitch.BatchMessage{Nonce: make([]byte, 16), Data: make([]byte, 128)}
+	}
+
+	base := lockstitch.NewProtocol("batch")
+	base.Mix("key", key)
+
+	messages := make([]lockstitch.BatchMessage, len(plaintexts))
+	for i, ciphertext := range lockstitch.SealBatch(base, "message", plaintexts) {
+		messages[i] = lockstitch.BatchMessage{Nonce: plaintexts[i].Nonce, Data: ciphertext}
+	}
+
+	b.Run("clone", func(b *testing.B) {
+		b.ReportAllocs()
+		b.SetBytes(int64(len(messages) * 128))
+		for b.Loop() {
+			for _, m := range messages {
+				protocol := base.Clone()
+				protocol.Mix("nonce", m.Nonce)
+				if _, err := protocol.Open("message", nil, m.Data); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+
+	b.Run("batch", func(b *testing.B) {
+		b.ReportAllocs()
+		b.SetBytes(int64(len(messages) * 128))
+		for b.Loop() {
+			if _, err := lockstitch.OpenBatch(base, "message", messages); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
 //nolint:gochecknoglobals // this is fine
 var lengths = []struct {
 	name string
@@ -0,0 +1,260 @@
+// Package channel implements an encrypted, authenticated, ordered record stream over a net.Conn using Lockstitch.
+//
+// A Conn uses one protocol for each direction. Each record is sealed with the sending protocol and opened with the
+// receiving protocol. Because every Seal and Open operation ratchets the protocol's state, records cannot be reordered,
+// replayed, or dropped without detection, and compromising a protocol's state does not reveal previous records.
+//
+// On the wire, each record consists of the big-endian 32-bit length of the sealed record, followed by the sealed
+// record. A sealed record is the output of Seal("record", type || payload), where type is a single byte indicating
+// whether the record contains data, a rekey notification, or a close notification.
+//
+// A Conn must be closed with Close, which sends a close notification, for the other party to distinguish the end of the
+// stream from a truncation.
+package channel
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/codahale/lockstitch-go"
+)
+
+// DefaultMaxRecordSize is the recommended maximum size, in bytes, of a record's payload.
+const DefaultMaxRecordSize = 16 * 1024
+
+// ErrRecordTooLarge is returned when a received record is larger than the maximum record size.
+var ErrRecordTooLarge = errors.New("channel: record too large")
+
+// A Conn is an encrypted, authenticated net.Conn.
+type Conn struct {
+	conn          net.Conn
+	maxRecordSize int
+
+	readMu  sync.Mutex
+	recv    *lockstitch.Protocol
+	readBuf []byte
+	pending []byte
+	readErr error
+
+	writeMu  sync.Mutex
+	send     *lockstitch.Protocol
+	writeBuf []byte
+	writeErr error
+}
+
+// New returns a Conn which sends records over conn using the send protocol and receives them using the recv protocol.
+// Records contain at most maxRecordSize bytes of payload. Both parties must use the same maximum record size, and each
+// party's send protocol must be identical to the other party's recv protocol (e.g., as returned by a handshake).
+//
+// The Conn takes ownership of the protocols, which must not be used afterward. New panics if maxRecordSize is not
+// positive.
+func New(conn net.Conn, send, recv *lockstitch.Protocol, maxRecordSize int) *Conn {
+	if maxRecordSize <= 0 {
+		panic("invalid argument to New: maxRecordSize must be positive")
+	}
+
+	return &Conn{
+		conn:          conn,
+		maxRecordSize: maxRecordSize,
+		readMu:        sync.Mutex{},
+		recv:          recv,
+		readBuf:       make([]byte, maxSealedLen(maxRecordSize)),
+		pending:       nil,
+		readErr:       nil,
+		writeMu:       sync.Mutex{},
+		send:          send,
+		writeBuf:      make([]byte, 0, headerLen+maxSealedLen(maxRecordSize)),
+		writeErr:      nil,
+	}
+}
+
+// Read reads data from the connection. It returns io.EOF once the other party has closed the connection, and
+// io.ErrUnexpectedEOF if the underlying connection ended without a close notification. If a record is not authentic,
+// Read returns lockstitch.ErrInvalidCiphertext and the connection can no longer be read.
+func (c *Conn) Read(b []byte) (int, error) {
+	c.readMu.Lock()
+	defer c.readMu.Unlock()
+
+	for len(c.pending) == 0 {
+		if c.readErr != nil {
+			return 0, c.readErr
+		}
+
+		c.readErr = c.readRecord()
+	}
+
+	n := copy(b, c.pending)
+	c.pending = c.pending[n:]
+
+	return n, nil
+}
+
+// Write writes data to the connection, splitting it into records of at most the maximum record size.
+func (c *Conn) Write(b []byte) (int, error) {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	n := 0
+	for len(b) > 0 {
+		chunk := b[:min(len(b), c.maxRecordSize)]
+		if err := c.writeRecord(recordData, chunk); err != nil {
+			return n, err
+		}
+		b = b[len(chunk):]
+		n += len(chunk)
+	}
+
+	return n, nil
+}
+
+// Rekey sends a rekey notification and replaces the sending protocol with a new protocol keyed with output derived from
+// the old protocol. When the other party reads the notification, it does the same with its receiving protocol.
+//
+// Because each record already ratchets the protocols, rekeying is not required for forward secrecy. It allows a party
+// to discard all state associated with previous records at a point both parties agree on.
+func (c *Conn) Rekey() error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	if err := c.writeRecord(recordRekey, nil); err != nil {
+		return err
+	}
+
+	c.send = rekey(c.send)
+
+	return nil
+}
+
+// Close sends a close notification and closes the underlying connection.
+func (c *Conn) Close() error {
+	c.writeMu.Lock()
+	err := c.writeRecord(recordCloseNotify, nil)
+	c.writeErr = net.ErrClosed
+	c.writeMu.Unlock()
+
+	return errors.Join(err, c.conn.Close())
+}
+
+// LocalAddr returns the local network address of the underlying connection.
+func (c *Conn) LocalAddr() net.Addr {
+	return c.conn.LocalAddr()
+}
+
+// RemoteAddr returns the remote network address of the underlying connection.
+func (c *Conn) RemoteAddr() net.Addr {
+	return c.conn.RemoteAddr()
+}
+
+// SetDeadline sets the read and write deadlines of the underlying connection. A Conn cannot be written to after a
+// write has timed out.
+func (c *Conn) SetDeadline(t time.Time) error {
+	return c.conn.SetDeadline(t)
+}
+
+// SetReadDeadline sets the read deadline of the underlying connection.
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
+// SetWriteDeadline sets the write deadline of the underlying connection. A Conn cannot be written to after a write has
+// timed out.
+func (c *Conn) SetWriteDeadline(t time.Time) error {
+	return c.conn.SetWriteDeadline(t)
+}
+
+// readRecord reads and opens the next record. It returns an error if the record is invalid or is a close notification.
+func (c *Conn) readRecord() error {
+	var header [headerLen]byte
+	if _, err := io.ReadFull(c.conn, header[:]); err != nil {
+		if errors.Is(err, io.EOF) {
+			return io.ErrUnexpectedEOF
+		}
+		return err
+	}
+
+	n := binary.BigEndian.Uint32(header[:])
+	if n > uint32(len(c.readBuf)) { //nolint:gosec // the maximum record size is always small
+		return ErrRecordTooLarge
+	}
+
+	if n < 1+lockstitch.TagLen {
+		return lockstitch.ErrInvalidCiphertext
+	}
+
+	sealed := c.readBuf[:n]
+	if _, err := io.ReadFull(c.conn, sealed); err != nil {
+		if errors.Is(err, io.EOF) {
+			return io.ErrUnexpectedEOF
+		}
+		return err
+	}
+
+	record, err := c.recv.Open("record", sealed[:0], sealed)
+	if err != nil {
+		return err
+	}
+
+	switch record[0] {
+	case recordData:
+		c.pending = record[1:]
+	case recordRekey:
+		c.recv = rekey(c.recv)
+	case recordCloseNotify:
+		return io.EOF
+	default:
+		return lockstitch.ErrInvalidCiphertext
+	}
+
+	return nil
+}
+
+// writeRecord seals and writes a record of the given type. The caller must hold writeMu. Write errors are sticky, as
+// a partially written record leaves the sending protocol out of sync with the other party's receiving protocol.
+func (c *Conn) writeRecord(recordType byte, payload []byte) error {
+	if c.writeErr != nil {
+		return c.writeErr
+	}
+
+	b := binary.BigEndian.AppendUint32(c.writeBuf[:0], uint32(1+len(payload)+lockstitch.TagLen)) //nolint:gosec // the maximum record size is always small
+	record := append(b[headerLen:], recordType)
+	record = append(record, payload...)
+	record = c.send.Seal("record", record[:0], record)
+
+	if _, err := c.conn.Write(b[:headerLen+len(record)]); err != nil {
+		c.writeErr = err
+		return err
+	}
+
+	return nil
+}
+
+// rekey returns a new protocol keyed with output derived from the given protocol.
+func rekey(p *lockstitch.Protocol) *lockstitch.Protocol {
+	key := p.Derive("rekey", nil, rekeyLen)
+	defer clear(key)
+
+	np := lockstitch.NewProtocol("lockstitch-go.channel.rekey")
+	np.Mix("key", key)
+
+	return np
+}
+
+// maxSealedLen returns the maximum length of a sealed record with the given maximum record size.
+func maxSealedLen(maxRecordSize int) int {
+	return 1 + maxRecordSize + lockstitch.TagLen
+}
+
+var _ net.Conn = (*Conn)(nil)
+
+const (
+	recordData        = 0x01 // A record containing application data.
+	recordRekey       = 0x02 // A record indicating the sender has rekeyed.
+	recordCloseNotify = 0x03 // A record indicating the sender has closed the connection.
+
+	headerLen = 4  // The length, in bytes, of a record header.
+	rekeyLen  = 32 // The length, in bytes, of the key derived when rekeying.
+)
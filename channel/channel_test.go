@@ -0,0 +1,215 @@
+package channel_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/codahale/lockstitch-go"
+	"github.com/codahale/lockstitch-go/channel"
+)
+
+func TestConn(t *testing.T) {
+	t.Parallel()
+
+	a, b := newPair(t, 100)
+	message := bytes.Repeat([]byte("this is an example"), 100)
+
+	go func() {
+		if _, err := a.Write(message); err != nil {
+			t.Error(err)
+		}
+
+		if err := a.Rekey(); err != nil {
+			t.Error(err)
+		}
+
+		if _, err := a.Write(message); err != nil {
+			t.Error(err)
+		}
+
+		if err := a.Close(); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	got, err := io.ReadAll(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := append(bytes.Clone(message), message...); !bytes.Equal(got, want) {
+		t.Errorf("ReadAll = %d bytes, want = %d bytes", len(got), len(want))
+	}
+
+	if _, err := b.Read(make([]byte, 1)); !errors.Is(err, io.EOF) {
+		t.Errorf("Read after close notification = %v, want = %v", err, io.EOF)
+	}
+}
+
+func TestConn_Bidirectional(t *testing.T) {
+	t.Parallel()
+
+	a, b := newPair(t, channel.DefaultMaxRecordSize)
+
+	go func() {
+		buf := make([]byte, 5)
+		if _, err := io.ReadFull(b, buf); err != nil {
+			t.Error(err)
+		}
+
+		if _, err := b.Write(bytes.ToUpper(buf)); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	if _, err := a.Write([]byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := make([]byte, 5)
+	if _, err := io.ReadFull(a, buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := string(buf), "HELLO"; got != want {
+		t.Errorf("Read = %q, want = %q", got, want)
+	}
+}
+
+func TestConn_Truncation(t *testing.T) {
+	t.Parallel()
+
+	raw := capture(t, 100, []byte("this is an example"))
+
+	// Remove the close notification.
+	truncated := raw[:len(raw)-(4+1+lockstitch.TagLen)]
+	if _, err := io.ReadAll(replay(t, truncated, 100)); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("ReadAll(without close notification) = %v, want = %v", err, io.ErrUnexpectedEOF)
+	}
+
+	// Truncate a record.
+	if _, err := io.ReadAll(replay(t, raw[:10], 100)); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("ReadAll(truncated record) = %v, want = %v", err, io.ErrUnexpectedEOF)
+	}
+}
+
+func TestConn_Tampering(t *testing.T) {
+	t.Parallel()
+
+	raw := capture(t, 8, []byte("this is an example"))
+
+	for i := range raw {
+		modified := bytes.Clone(raw)
+		modified[i] ^= 1
+
+		if _, err := io.ReadAll(replay(t, modified, 8)); err == nil {
+			t.Errorf("ReadAll(stream with byte %d modified) did not return an error", i)
+		}
+	}
+
+	// Drop the first record.
+	recordLen := 4 + 1 + 8 + lockstitch.TagLen
+	if _, err := io.ReadAll(replay(t, raw[recordLen:], 8)); !errors.Is(err, lockstitch.ErrInvalidCiphertext) {
+		t.Errorf("ReadAll(dropped record) = %v, want = %v", err, lockstitch.ErrInvalidCiphertext)
+	}
+
+	// Duplicate the first record.
+	duplicated := append(bytes.Clone(raw[:recordLen]), raw...)
+	if _, err := io.ReadAll(replay(t, duplicated, 8)); !errors.Is(err, lockstitch.ErrInvalidCiphertext) {
+		t.Errorf("ReadAll(duplicated record) = %v, want = %v", err, lockstitch.ErrInvalidCiphertext)
+	}
+}
+
+func TestConn_MaxRecordSize(t *testing.T) {
+	t.Parallel()
+
+	raw := capture(t, 100, bytes.Repeat([]byte{'A'}, 100))
+
+	if _, err := io.ReadAll(replay(t, raw, 50)); !errors.Is(err, channel.ErrRecordTooLarge) {
+		t.Errorf("ReadAll(oversized record) = %v, want = %v", err, channel.ErrRecordTooLarge)
+	}
+
+	header := binary.BigEndian.AppendUint32(nil, 1<<31)
+	if _, err := io.ReadAll(replay(t, header, 50)); !errors.Is(err, channel.ErrRecordTooLarge) {
+		t.Errorf("ReadAll(huge record header) = %v, want = %v", err, channel.ErrRecordTooLarge)
+	}
+}
+
+// newPair returns two connected Conns.
+func newPair(t *testing.T, maxRecordSize int) (a, b *channel.Conn) {
+	t.Helper()
+
+	x, y := net.Pipe()
+	aToB, bToA := newProtocols()
+	a = channel.New(x, aToB.Clone(), bToA.Clone(), maxRecordSize)
+	b = channel.New(y, bToA, aToB, maxRecordSize)
+
+	t.Cleanup(func() {
+		_ = x.Close()
+		_ = y.Close()
+	})
+
+	return a, b
+}
+
+// capture writes the message and a close notification to a Conn and returns the raw bytes written.
+func capture(t *testing.T, maxRecordSize int, message []byte) []byte {
+	t.Helper()
+
+	x, y := net.Pipe()
+	aToB, _ := newProtocols()
+	a := channel.New(x, aToB, lockstitch.NewProtocol("unused"), maxRecordSize)
+
+	go func() {
+		if _, err := a.Write(message); err != nil {
+			t.Error(err)
+		}
+
+		if err := a.Close(); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	raw, err := io.ReadAll(y)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return raw
+}
+
+// replay returns a Conn which reads the given raw bytes.
+func replay(t *testing.T, raw []byte, maxRecordSize int) *channel.Conn {
+	t.Helper()
+
+	x, y := net.Pipe()
+	aToB, _ := newProtocols()
+	b := channel.New(y, lockstitch.NewProtocol("unused"), aToB, maxRecordSize)
+
+	go func() {
+		_, _ = x.Write(raw)
+		_ = x.Close()
+	}()
+
+	t.Cleanup(func() {
+		_ = y.Close()
+	})
+
+	return b
+}
+
+func newProtocols() (aToB, bToA *lockstitch.Protocol) {
+	p := lockstitch.NewProtocol("channel test")
+	p.Mix("key", []byte("this is a shared secret"))
+
+	aToB = p.Clone()
+	aToB.Mix("a to b", nil)
+	bToA = p
+	bToA.Mix("b to a", nil)
+
+	return aToB, bToA
+}
@@ -0,0 +1,61 @@
+// Command lockstitch-gen generates Go code from a declarative Lockstitch protocol schema.
+//
+// Usage:
+//
+//	lockstitch-gen -pkg <package> [-o <output file>] <schema file>
+//
+// If no output file is given, the generated code is written to standard output. See the schema package for the format
+// of schema files and the generated code.
+package main
+
+import (
+	"flag"
+	"go/token"
+	"log"
+	"os"
+
+	"github.com/codahale/lockstitch-go/schema"
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("lockstitch-gen: ")
+
+	pkg := flag.String("pkg", "", "the name of the generated code's package")
+	out := flag.String("o", "", "the output file (default: standard output)")
+	flag.Parse()
+
+	if *pkg == "" || flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if !token.IsIdentifier(*pkg) {
+		log.Fatalf("invalid package name: %q", *pkg)
+	}
+
+	data, err := os.ReadFile(flag.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	s, err := schema.Parse(data)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	code, err := schema.Generate(s, *pkg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *out == "" {
+		_, err = os.Stdout.Write(code)
+	} else {
+		err = os.WriteFile(*out, code, 0o600)
+	}
+
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,77 @@
+package lockstitch
+
+import (
+	"io"
+)
+
+// A DRBG is a deterministic random bit generator which uses a protocol. It implements io.Reader.
+//
+// A DRBG is equivalent to initializing a protocol with a domain separation string, mixing the seed into the protocol
+// using the label "seed", and deriving each request's output using the label "output". Because each Derive operation
+// ratchets the protocol's state, disclosing a DRBG's state does not reveal its previous outputs. Reseeding mixes new
+// entropy into the protocol using the label "reseed", which makes future outputs unpredictable to an adversary who has
+// disclosed its previous state.
+//
+// A DRBG is not safe for concurrent use.
+type DRBG struct {
+	p             *Protocol
+	entropy       io.Reader
+	reseedCounter uint64
+}
+
+// NewDRBG returns a DRBG which is seeded with the given domain separation string and seed. Two DRBGs with the same
+// domain separation string and seed will produce the same output for the same sequence of requests.
+func NewDRBG(domain string, seed []byte) *DRBG {
+	p := NewProtocol(domain)
+	p.Mix("seed", seed)
+
+	return &DRBG{p: p, entropy: nil, reseedCounter: 0}
+}
+
+// Read fills b with pseudorandom output as a single request. If prediction resistance is enabled, Read first reseeds
+// the DRBG with entropy from the prediction resistance source, returning any error from it.
+//
+// To use a DRBG as the rand argument to crypto/ecdh and crypto/ecdsa key generation functions, which ignore custom
+// random sources as of Go 1.26, set GODEBUG=cryptocustomrand=1. Those functions randomly read an extra byte from custom
+// random sources, so the keys they generate from a DRBG are not reproducible.
+func (d *DRBG) Read(b []byte) (int, error) {
+	if d.entropy != nil {
+		var entropy [predictionResistanceLen]byte
+		if _, err := io.ReadFull(d.entropy, entropy[:]); err != nil {
+			return 0, err
+		}
+		d.Reseed(entropy[:])
+	}
+
+	for out := b; len(out) > 0; {
+		n := int(min(uint64(len(out)), maxDeriveLen)) //nolint:gosec // n is always <= len(out)
+		d.p.Derive("output", out[:0], n)
+		out = out[n:]
+	}
+	d.reseedCounter++
+
+	return len(b), nil
+}
+
+// Reseed mixes the given entropy into the DRBG's state and resets its reseed counter.
+func (d *DRBG) Reseed(entropy []byte) {
+	d.p.Mix("reseed", entropy)
+	d.reseedCounter = 0
+}
+
+// ReseedCounter returns the number of requests the DRBG has fulfilled since it was seeded or last reseeded.
+func (d *DRBG) ReseedCounter() uint64 {
+	return d.reseedCounter
+}
+
+// SetPredictionResistance enables prediction resistance by causing the DRBG to reseed with entropy read from the given
+// source (e.g., crypto/rand.Reader) before each request. Passing nil disables prediction resistance. The DRBG's output
+// is no longer deterministic while prediction resistance is enabled.
+func (d *DRBG) SetPredictionResistance(source io.Reader) {
+	d.entropy = source
+}
+
+var _ io.Reader = (*DRBG)(nil)
+
+// predictionResistanceLen is the length, in bytes, of the entropy read from the prediction resistance source.
+const predictionResistanceLen = 32
@@ -0,0 +1,134 @@
+package lockstitch_test
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"encoding/hex"
+	"errors"
+	"io"
+	"testing"
+	"testing/iotest"
+
+	"github.com/codahale/lockstitch-go"
+)
+
+func TestDRBG(t *testing.T) {
+	t.Parallel()
+
+	d := lockstitch.NewDRBG("com.example.drbg", []byte("seed"))
+
+	out := make([]byte, 16)
+	if _, err := d.Read(out); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := hex.EncodeToString(out), "5133dca0c2568731a77905fba0535948"; got != want {
+		t.Errorf("Read = %v, want = %v", got, want)
+	}
+
+	if got, want := d.ReseedCounter(), uint64(1); got != want {
+		t.Errorf("ReseedCounter = %d, want = %d", got, want)
+	}
+
+	// Each request ratchets the DRBG's state.
+	next := make([]byte, 16)
+	if _, err := d.Read(next); err != nil {
+		t.Fatal(err)
+	}
+
+	if bytes.Equal(out, next) {
+		t.Error("Read returned the same output twice")
+	}
+
+	// Reseeding resets the reseed counter and changes the output.
+	a, b := lockstitch.NewDRBG("com.example.drbg", []byte("seed")), lockstitch.NewDRBG("com.example.drbg", []byte("seed"))
+	a.Reseed([]byte("entropy"))
+	if got, want := a.ReseedCounter(), uint64(0); got != want {
+		t.Errorf("ReseedCounter = %d, want = %d", got, want)
+	}
+
+	if bytes.Equal(read(t, a, 16), read(t, b, 16)) {
+		t.Error("Reseed did not change the output")
+	}
+}
+
+func TestDRBG_Deterministic(t *testing.T) {
+	t.Parallel()
+
+	a, b := lockstitch.NewDRBG("com.example.drbg", []byte("seed")), lockstitch.NewDRBG("com.example.drbg", []byte("seed"))
+	for _, n := range []int{0, 1, 32, 1000} {
+		if got, want := read(t, a, n), read(t, b, n); !bytes.Equal(got, want) {
+			t.Errorf("Read(%d) = %x, want = %x", n, got, want)
+		}
+	}
+
+	if bytes.Equal(read(t, lockstitch.NewDRBG("com.example.drbg", []byte("other seed")), 16), read(t, a, 16)) {
+		t.Error("different seeds produced the same output")
+	}
+}
+
+func TestDRBG_PredictionResistance(t *testing.T) {
+	t.Parallel()
+
+	a, b := lockstitch.NewDRBG("com.example.drbg", []byte("seed")), lockstitch.NewDRBG("com.example.drbg", []byte("seed"))
+	a.SetPredictionResistance(bytes.NewReader(bytes.Repeat([]byte{1}, 32)))
+
+	if bytes.Equal(read(t, a, 16), read(t, b, 16)) {
+		t.Error("prediction resistance did not change the output")
+	}
+
+	if got, want := a.ReseedCounter(), uint64(1); got != want {
+		t.Errorf("ReseedCounter = %d, want = %d", got, want)
+	}
+
+	// The entropy source is exhausted, so the next request fails.
+	if _, err := a.Read(make([]byte, 16)); !errors.Is(err, io.EOF) {
+		t.Errorf("Read = %v, want = %v", err, io.EOF)
+	}
+
+	a.SetPredictionResistance(iotest.ErrReader(errTest))
+	if _, err := a.Read(make([]byte, 16)); !errors.Is(err, errTest) {
+		t.Errorf("Read = %v, want = %v", err, errTest)
+	}
+
+	a.SetPredictionResistance(nil)
+	if _, err := a.Read(make([]byte, 16)); err != nil {
+		t.Errorf("Read = %v, want = nil", err)
+	}
+}
+
+//nolint:paralleltest // uses t.Setenv
+func TestDRBG_KeyGeneration(t *testing.T) {
+	t.Setenv("GODEBUG", "cryptocustomrand=1")
+
+	// crypto/ecdh and crypto/ecdsa randomly read an extra byte from custom random sources, so keys generated from the
+	// same seed are not guaranteed to be identical. Instead, check that key generation reads from the DRBG.
+	d := lockstitch.NewDRBG("com.example.drbg", []byte("seed"))
+
+	if _, err := ecdh.X25519().GenerateKey(d); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ecdsa.GenerateKey(elliptic.P256(), d); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, unused := read(t, d, 16), read(t, lockstitch.NewDRBG("com.example.drbg", []byte("seed")), 16); bytes.Equal(got, unused) {
+		t.Errorf("key generation did not read from the DRBG")
+	}
+}
+
+func read(tb testing.TB, r io.Reader, n int) []byte {
+	tb.Helper()
+
+	b := make([]byte, n)
+	if _, err := io.ReadFull(r, b); err != nil {
+		tb.Fatal(err)
+	}
+
+	return b
+}
+
+var errTest = errors.New("test error")
@@ -0,0 +1,236 @@
+// Package fpe implements format-preserving encryption using a Feistel network with Lockstitch as the round function.
+//
+// A Cipher encrypts strings of numerals in a given radix (e.g., strings of decimal digits) to strings of numerals of the
+// same length in the same radix. The construction follows FF1: the input is split into two halves, and each of ten
+// rounds adds the output of the round function, modulo the radix raised to the length of the half, to one half and then
+// swaps the halves.
+//
+// The round function clones a protocol which has the key, radix, input length, and tweak mixed into it, mixes in the
+// round index and one half of the input, and derives enough output to reduce uniformly modulo the size of the other
+// half's domain.
+//
+// Like FF1, a Cipher requires the domain (i.e., the radix raised to the length of the input) to contain at least
+// 1,000,000 values. Format-preserving encryption is deterministic: encrypting the same input with the same tweak
+// produces the same output. Tweaks should be used to prevent this where possible.
+package fpe
+
+import (
+	"encoding/binary"
+	"errors"
+	"math/big"
+	"slices"
+
+	"github.com/codahale/lockstitch-go"
+)
+
+const (
+	// MinRadix and MaxRadix are the minimum and maximum radices.
+	MinRadix, MaxRadix = 2, 1 << 16
+
+	// MaxLen is the maximum length, in numerals, of an input.
+	MaxLen = 1 << 16
+
+	// MinKeySize is the minimum size, in bytes, of a key.
+	MinKeySize = 16
+)
+
+var (
+	// ErrInvalidKey is returned when a key is shorter than MinKeySize.
+	ErrInvalidKey = errors.New("fpe: invalid key")
+
+	// ErrInvalidRadix is returned when a radix is outside the range [MinRadix, MaxRadix].
+	ErrInvalidRadix = errors.New("fpe: invalid radix")
+
+	// ErrInvalidLength is returned when an input is too short for its domain to contain at least 1,000,000 values, or
+	// is longer than MaxLen.
+	ErrInvalidLength = errors.New("fpe: invalid length")
+
+	// ErrInvalidNumeral is returned when an input contains a numeral which is not valid in the cipher's radix.
+	ErrInvalidNumeral = errors.New("fpe: invalid numeral")
+)
+
+// A Cipher is a format-preserving cipher for numeral strings of a given radix.
+type Cipher struct {
+	base  *lockstitch.Protocol
+	radix int
+}
+
+// NewCipher returns a Cipher with the given key and radix.
+func NewCipher(key []byte, radix int) (*Cipher, error) {
+	if len(key) < MinKeySize {
+		return nil, ErrInvalidKey
+	}
+
+	if radix < MinRadix || radix > MaxRadix {
+		return nil, ErrInvalidRadix
+	}
+
+	p := lockstitch.NewProtocol("lockstitch-go.fpe")
+	p.Mix("key", key)
+	p.Mix("radix", binary.BigEndian.AppendUint32(nil, uint32(radix))) //nolint:gosec // radix <= MaxRadix
+
+	return &Cipher{base: p, radix: radix}, nil
+}
+
+// Encrypt encrypts the numerals with the given tweak and returns the encrypted numerals, which have the same length and
+// radix.
+func (c *Cipher) Encrypt(numerals []uint16, tweak []byte) ([]uint16, error) {
+	return c.crypt(numerals, tweak, false)
+}
+
+// Decrypt decrypts the numerals with the given tweak and returns the decrypted numerals.
+func (c *Cipher) Decrypt(numerals []uint16, tweak []byte) ([]uint16, error) {
+	return c.crypt(numerals, tweak, true)
+}
+
+// EncryptString encrypts the string s, each character of which must be in the given alphabet, with the given tweak. The
+// alphabet must contain exactly radix unique characters.
+func (c *Cipher) EncryptString(alphabet, s string, tweak []byte) (string, error) {
+	return c.cryptString(alphabet, s, tweak, false)
+}
+
+// DecryptString decrypts the string s, each character of which must be in the given alphabet, with the given tweak.
+// The alphabet must contain exactly radix unique characters.
+func (c *Cipher) DecryptString(alphabet, s string, tweak []byte) (string, error) {
+	return c.cryptString(alphabet, s, tweak, true)
+}
+
+func (c *Cipher) cryptString(alphabet, s string, tweak []byte, decrypt bool) (string, error) {
+	chars := []rune(alphabet)
+	if len(chars) != c.radix {
+		return "", ErrInvalidRadix
+	}
+
+	index := make(map[rune]uint16, len(chars))
+	for i, r := range chars {
+		if _, ok := index[r]; ok {
+			return "", ErrInvalidRadix
+		}
+		index[r] = uint16(i) //nolint:gosec // i < MaxRadix
+	}
+
+	var numerals []uint16
+	for _, r := range s {
+		i, ok := index[r]
+		if !ok {
+			return "", ErrInvalidNumeral
+		}
+		numerals = append(numerals, i)
+	}
+
+	out, err := c.crypt(numerals, tweak, decrypt)
+	if err != nil {
+		return "", err
+	}
+
+	runes := make([]rune, len(out))
+	for i, n := range out {
+		runes[i] = chars[n]
+	}
+
+	return string(runes), nil
+}
+
+func (c *Cipher) crypt(numerals []uint16, tweak []byte, decrypt bool) ([]uint16, error) {
+	n := len(numerals)
+	if n < 2 || n > MaxLen {
+		return nil, ErrInvalidLength
+	}
+
+	radix := big.NewInt(int64(c.radix))
+	if new(big.Int).Exp(radix, big.NewInt(int64(n)), nil).Cmp(big.NewInt(minDomainSize)) < 0 {
+		return nil, ErrInvalidLength
+	}
+
+	for _, x := range numerals {
+		if int(x) >= c.radix {
+			return nil, ErrInvalidNumeral
+		}
+	}
+
+	// Mix the input length and the tweak into a clone of the base protocol.
+	t := c.base.Clone()
+	t.Mix("length", binary.BigEndian.AppendUint32(nil, uint32(n))) //nolint:gosec // n <= MaxLen
+	t.Mix("tweak", tweak)
+
+	// Split the input into two halves and calculate the size of each half's domain.
+	u := n / 2
+	a, b := slices.Clone(numerals[:u]), slices.Clone(numerals[u:])
+	modU := new(big.Int).Exp(radix, big.NewInt(int64(u)), nil)
+	modV := new(big.Int).Exp(radix, big.NewInt(int64(n-u)), nil)
+
+	if !decrypt {
+		for i := range rounds {
+			mod := modU
+			if i%2 == 1 {
+				mod = modV
+			}
+
+			// C = (NUM(A) + F(i, B)) mod radix^m, then A = B and B = C.
+			y := c.round(t, i, b, mod)
+			y.Add(y, c.num(a))
+			y.Mod(y, mod)
+			a, b = b, c.str(y, len(a))
+		}
+	} else {
+		for i := rounds - 1; i >= 0; i-- {
+			mod := modU
+			if i%2 == 1 {
+				mod = modV
+			}
+
+			// C = B, B = A, then A = (NUM(C) - F(i, B)) mod radix^m.
+			y := c.round(t, i, a, mod)
+			y.Sub(c.num(b), y)
+			y.Mod(y, mod)
+			a, b = c.str(y, len(b)), a
+		}
+	}
+
+	return append(a, b...), nil
+}
+
+// round returns the output of the round function for round i and the half b, reduced modulo mod.
+func (c *Cipher) round(t *lockstitch.Protocol, i int, b []uint16, mod *big.Int) *big.Int {
+	input := make([]byte, 0, 2*len(b))
+	for _, x := range b {
+		input = binary.BigEndian.AppendUint16(input, x)
+	}
+
+	r := t.Clone()
+	r.Mix("round", []byte{byte(i)})
+	r.Mix("input", input)
+
+	// Derive enough output to make the bias of the reduction negligible.
+	y := r.Derive("output", nil, (mod.BitLen()+7)/8+extraRoundBytes)
+	return new(big.Int).Mod(new(big.Int).SetBytes(y), mod)
+}
+
+// num returns the integer represented by the numerals in big-endian order.
+func (c *Cipher) num(x []uint16) *big.Int {
+	radix := big.NewInt(int64(c.radix))
+	y := new(big.Int)
+	for _, n := range x {
+		y.Mul(y, radix)
+		y.Add(y, big.NewInt(int64(n)))
+	}
+	return y
+}
+
+// str returns the m numerals representing y in big-endian order.
+func (c *Cipher) str(y *big.Int, m int) []uint16 {
+	radix := big.NewInt(int64(c.radix))
+	x := make([]uint16, m)
+	rem := new(big.Int)
+	for i := m - 1; i >= 0; i-- {
+		y.QuoRem(y, radix, rem)
+		x[i] = uint16(rem.Uint64()) //nolint:gosec // rem < radix <= MaxRadix
+	}
+	return x
+}
+
+const (
+	rounds          = 10        // The number of Feistel rounds.
+	minDomainSize   = 1_000_000 // The minimum size of the domain.
+	extraRoundBytes = 8         // The number of bytes derived in excess of the size of a half's domain.
+)
@@ -0,0 +1,180 @@
+package fpe_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/codahale/lockstitch-go/fpe"
+)
+
+const digits = "0123456789"
+
+func TestCipher(t *testing.T) {
+	t.Parallel()
+
+	c, err := fpe.NewCipher([]byte("yellow submarine"), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plaintext := []uint16{4, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1}
+	ciphertext, err := c.Encrypt(plaintext, []byte("tweak"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := ciphertext, []uint16{7, 9, 4, 5, 4, 9, 6, 2, 8, 5, 0, 8, 4, 4, 4, 0}; !slices.Equal(got, want) {
+		t.Errorf("Encrypt = %v, want = %v", got, want)
+	}
+
+	got, err := c.Decrypt(ciphertext, []byte("tweak"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := plaintext; !slices.Equal(got, want) {
+		t.Errorf("Decrypt = %v, want = %v", got, want)
+	}
+
+	// The tweak changes the permutation.
+	other, err := c.Encrypt(plaintext, []byte("another tweak"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if slices.Equal(other, ciphertext) {
+		t.Errorf("Encrypt(another tweak) = %v, want != %v", other, ciphertext)
+	}
+
+	// The key changes the permutation.
+	c2, err := fpe.NewCipher([]byte("another key here"), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other, err = c2.Encrypt(plaintext, []byte("tweak"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if slices.Equal(other, ciphertext) {
+		t.Errorf("Encrypt(another key) = %v, want != %v", other, ciphertext)
+	}
+}
+
+func TestCipher_String(t *testing.T) {
+	t.Parallel()
+
+	c, err := fpe.NewCipher([]byte("yellow submarine"), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ciphertext, err := c.EncryptString(digits, "4111111111111111", []byte("tweak"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := ciphertext, "7945496285084440"; got != want {
+		t.Errorf("EncryptString = %q, want = %q", got, want)
+	}
+
+	plaintext, err := c.DecryptString(digits, ciphertext, []byte("tweak"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := plaintext, "4111111111111111"; got != want {
+		t.Errorf("DecryptString = %q, want = %q", got, want)
+	}
+
+	if _, err := c.EncryptString(digits, "4111-1111", nil); !errors.Is(err, fpe.ErrInvalidNumeral) {
+		t.Errorf("EncryptString(invalid numeral) = %v, want = %v", err, fpe.ErrInvalidNumeral)
+	}
+
+	if _, err := c.EncryptString("0123456780", "4111111111", nil); !errors.Is(err, fpe.ErrInvalidRadix) {
+		t.Errorf("EncryptString(duplicate numeral) = %v, want = %v", err, fpe.ErrInvalidRadix)
+	}
+
+	if _, err := c.EncryptString("0123456789abcdef", "4111111111", nil); !errors.Is(err, fpe.ErrInvalidRadix) {
+		t.Errorf("EncryptString(wrong radix) = %v, want = %v", err, fpe.ErrInvalidRadix)
+	}
+}
+
+func TestCipher_Permutation(t *testing.T) {
+	t.Parallel()
+
+	// A radix-1000 domain of length 2 contains exactly 1,000,000 values. Check every input to the first 1000 maps to a
+	// distinct output and back.
+	c, err := fpe.NewCipher([]byte("yellow submarine"), 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seen := make(map[[2]uint16]bool)
+	for i := range uint16(1000) {
+		plaintext := []uint16{7, i}
+		ciphertext, err := c.Encrypt(plaintext, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if ciphertext[0] >= 1000 || ciphertext[1] >= 1000 {
+			t.Fatalf("Encrypt(%v) = %v, which is out of range", plaintext, ciphertext)
+		}
+
+		k := [2]uint16(ciphertext)
+		if seen[k] {
+			t.Fatalf("Encrypt(%v) = %v, which is a duplicate", plaintext, ciphertext)
+		}
+		seen[k] = true
+
+		got, err := c.Decrypt(ciphertext, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !slices.Equal(got, plaintext) {
+			t.Errorf("Decrypt(%v) = %v, want = %v", ciphertext, got, plaintext)
+		}
+	}
+}
+
+func TestCipher_Errors(t *testing.T) {
+	t.Parallel()
+
+	if _, err := fpe.NewCipher([]byte("too short"), 10); !errors.Is(err, fpe.ErrInvalidKey) {
+		t.Errorf("NewCipher(short key) = %v, want = %v", err, fpe.ErrInvalidKey)
+	}
+
+	for _, radix := range []int{0, 1, fpe.MaxRadix + 1} {
+		if _, err := fpe.NewCipher([]byte("yellow submarine"), radix); !errors.Is(err, fpe.ErrInvalidRadix) {
+			t.Errorf("NewCipher(radix=%d) = %v, want = %v", radix, err, fpe.ErrInvalidRadix)
+		}
+	}
+
+	c, err := fpe.NewCipher([]byte("yellow submarine"), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 10^5 is smaller than the minimum domain size.
+	if _, err := c.Encrypt([]uint16{1, 2, 3, 4, 5}, nil); !errors.Is(err, fpe.ErrInvalidLength) {
+		t.Errorf("Encrypt(short input) = %v, want = %v", err, fpe.ErrInvalidLength)
+	}
+
+	if _, err := c.Encrypt([]uint16{1, 2, 3, 4, 5, 10}, nil); !errors.Is(err, fpe.ErrInvalidNumeral) {
+		t.Errorf("Encrypt(invalid numeral) = %v, want = %v", err, fpe.ErrInvalidNumeral)
+	}
+
+	// A single numeral cannot be split, regardless of radix.
+	c, err = fpe.NewCipher([]byte("yellow submarine"), fpe.MaxRadix)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.Encrypt([]uint16{1}, nil); !errors.Is(err, fpe.ErrInvalidLength) {
+		t.Errorf("Encrypt(single numeral) = %v, want = %v", err, fpe.ErrInvalidLength)
+	}
+}
@@ -2,9 +2,13 @@ package lockstitch_test
 
 import (
 	"bytes"
+	"crypto/ecdh"
+	"slices"
 	"testing"
 
 	"github.com/codahale/lockstitch-go"
+	"github.com/codahale/lockstitch-go/fpe"
+	"github.com/codahale/lockstitch-go/signcrypt"
 )
 
 func FuzzStream(f *testing.F) {
@@ -83,3 +87,164 @@ func FuzzAEAD(f *testing.F) {
 		}
 	})
 }
+
+func FuzzSealedStream(f *testing.F) {
+	f.Add("yellow submarine", []byte("this is a longer message which spans several chunks"), uint8(8), byte(0), uint(1))
+	f.Fuzz(func(t *testing.T, key string, plaintext []byte, chunkSizeRaw uint8, attack byte, idx uint) {
+		chunkSize := int(chunkSizeRaw%64) + 1
+		sealed, stateA := sealStream(t, key, plaintext, chunkSize)
+
+		// check for decryption of authentic stream
+		opened, stateB, err := openStream(key, sealed, chunkSize)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := opened, plaintext; !bytes.Equal(got, want) {
+			t.Errorf("openStream(key, sealed) = %v, want = %v", got, want)
+		}
+		if !bytes.Equal(stateA, stateB) {
+			t.Errorf("divergent posterior protocol states: %v != %v", stateA, stateB)
+		}
+
+		// split the sealed stream into its chunks
+		var chunks [][]byte
+		for c := sealed; len(c) > 0; {
+			n := min(chunkSize+lockstitch.TagLen, len(c))
+			chunks = append(chunks, c[:n])
+			c = c[n:]
+		}
+
+		// check for non-decryption of inauthentic streams
+		var modified []byte
+		switch attack % 4 {
+		case 0: // truncation at a chunk boundary
+			if len(chunks) < 2 {
+				t.Skip()
+			}
+			modified = bytes.Join(chunks[:int(idx%uint(len(chunks)-1))+1], nil)
+		case 1: // truncation at an arbitrary point
+			modified = sealed[:int(idx%uint(len(sealed)))]
+		case 2: // chunk reordering
+			if len(chunks) < 2 {
+				t.Skip()
+			}
+			i := int(idx % uint(len(chunks)-1))
+			chunks[i], chunks[i+1] = chunks[i+1], chunks[i]
+			modified = bytes.Join(chunks, nil)
+		case 3: // chunk duplication
+			i := int(idx % uint(len(chunks)))
+			chunks = slices.Insert(chunks, i, chunks[i])
+			modified = bytes.Join(chunks, nil)
+		}
+
+		if got, _, err := openStream(key, modified, chunkSize); err == nil {
+			t.Errorf("openStream(key, modified) = %v, want = nil", got)
+		}
+	})
+}
+
+func FuzzSigncryption(f *testing.F) {
+	newKey := func(t *testing.T, seed string) *ecdh.PrivateKey {
+		t.Helper()
+
+		protocol := lockstitch.NewProtocol("signcryption key")
+		protocol.Mix("seed", []byte(seed))
+		k, err := ecdh.P256().NewPrivateKey(protocol.Derive("private key", nil, 32))
+		if err != nil {
+			t.Skip()
+		}
+		return k
+	}
+
+	f.Add("alice", "bob", []byte("hello world"), uint(2), byte(100))
+	f.Fuzz(func(t *testing.T, senderSeed, receiverSeed string, plaintext []byte, idx uint, mask byte) {
+		if mask == 0 || senderSeed == receiverSeed {
+			t.Skip()
+		}
+
+		sender, receiver := newKey(t, senderSeed), newKey(t, receiverSeed)
+
+		c, err := signcrypt.Signcrypt(sender, receiver.PublicKey(), plaintext)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// check for decryption of authentic ciphertext
+		p2, err := signcrypt.Unsigncrypt(receiver, sender.PublicKey(), c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := p2, plaintext; !bytes.Equal(got, want) {
+			t.Errorf("Unsigncrypt(receiver, sender, c) = %v, want = %v", got, want)
+		}
+
+		// check for non-decryption with swapped keys
+		if got, err := signcrypt.Unsigncrypt(sender, receiver.PublicKey(), c); err == nil {
+			t.Errorf("Unsigncrypt(sender, receiver, c) = %v, want = nil", got)
+		}
+
+		// check for non-decryption of inauthentic ciphertext
+		c[int(idx)%len(c)] ^= mask
+
+		if got, err := signcrypt.Unsigncrypt(receiver, sender.PublicKey(), c); err == nil {
+			t.Errorf("Unsigncrypt(receiver, sender, c) = %v, want = nil", got)
+		}
+	})
+}
+
+func FuzzPermutation(f *testing.F) {
+	f.Add([]byte("yellow submarine"), uint32(10), uint8(6), []byte("tweak"), []byte("input one"), []byte("input two"))
+	f.Fuzz(func(t *testing.T, key []byte, radix uint32, length uint8, tweak []byte, seedA, seedB []byte) {
+		radix = radix%(fpe.MaxRadix-fpe.MinRadix+1) + fpe.MinRadix
+		c, err := fpe.NewCipher(slices.Concat([]byte("yellow submarine"), key), int(radix))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// derive two inputs in the domain from the seeds
+		numerals := func(seed []byte) []uint16 {
+			protocol := lockstitch.NewProtocol("fpe input")
+			protocol.Mix("seed", seed)
+			b := protocol.Derive("numerals", nil, 2*int(length))
+			x := make([]uint16, length)
+			for i := range x {
+				x[i] = uint16((uint32(b[2*i])<<8 | uint32(b[2*i+1])) % radix)
+			}
+			return x
+		}
+		a, b := numerals(seedA), numerals(seedB)
+
+		ca, err := c.Encrypt(a, tweak)
+		if err != nil {
+			// the domain is too small
+			t.Skip()
+		}
+
+		cb, err := c.Encrypt(b, tweak)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// check that outputs are in the domain
+		for _, x := range slices.Concat(ca, cb) {
+			if uint32(x) >= radix {
+				t.Fatalf("Encrypt produced numeral %d for radix %d", x, radix)
+			}
+		}
+
+		// check that the cipher is injective
+		if got, want := slices.Equal(ca, cb), slices.Equal(a, b); got != want {
+			t.Errorf("Encrypt(%v) == Encrypt(%v) is %v, want = %v", a, b, got, want)
+		}
+
+		// check that decryption inverts encryption
+		pa, err := c.Decrypt(ca, tweak)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got, want := pa, a; !slices.Equal(got, want) {
+			t.Errorf("Decrypt(Encrypt(x)) = %v, want = %v", got, want)
+		}
+	})
+}
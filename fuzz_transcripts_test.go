@@ -38,6 +38,7 @@ func FuzzProtocolDivergence(f *testing.F) {
 		p1 := lockstitch.NewProtocol("divergence")
 		p2 := lockstitch.NewProtocol("divergence")
 
+		var operations []operation
 		for range opCount % 50 {
 			opTypeRaw, err := tp.GetByte()
 			if err != nil {
@@ -59,6 +60,12 @@ func FuzzProtocolDivergence(f *testing.F) {
 
 				p1.Mix(label, input)
 				p2.Mix(label, input)
+
+				operations = append(operations, operation{ //nolint:exhaustruct // it's fine
+					opType: 0,
+					label:  label,
+					input:  input,
+				})
 			case 1: // Derive
 				n, err := tp.GetUint16()
 				if err != nil || n == 0 {
@@ -66,8 +73,16 @@ func FuzzProtocolDivergence(f *testing.F) {
 				}
 
 				res1, res2 := p1.Derive(label, nil, int(n)), p2.Derive(label, nil, int(n))
+
+				operations = append(operations, operation{ //nolint:exhaustruct // it's fine
+					opType: 1,
+					label:  label,
+					n:      int(n),
+				})
+
 				if !bytes.Equal(res1, res2) {
-					t.Fatalf("Divergent Derive outputs: %x != %x", res1, res2)
+					t.Fatalf("Divergent Derive outputs: %x != %x\n%s", res1, res2,
+						replayDivergence(operations))
 				}
 			case 2: // Encrypt
 				input, err := tp.GetBytes()
@@ -76,8 +91,16 @@ func FuzzProtocolDivergence(f *testing.F) {
 				}
 
 				res1, res2 := p1.Encrypt(label, nil, input), p2.Encrypt(label, nil, input)
+
+				operations = append(operations, operation{ //nolint:exhaustruct // it's fine
+					opType: 2,
+					label:  label,
+					input:  input,
+				})
+
 				if !bytes.Equal(res1, res2) {
-					t.Fatalf("Divergent Encrypt outputs: %x != %x", res1, res2)
+					t.Fatalf("Divergent Encrypt outputs: %x != %x\n%s", res1, res2,
+						replayDivergence(operations))
 				}
 			case 3: // Seal
 				input, err := tp.GetBytes()
@@ -86,8 +109,16 @@ func FuzzProtocolDivergence(f *testing.F) {
 				}
 
 				res1, res2 := p1.Seal(label, nil, input), p2.Seal(label, nil, input)
+
+				operations = append(operations, operation{ //nolint:exhaustruct // it's fine
+					opType: 3,
+					label:  label,
+					input:  input,
+				})
+
 				if !bytes.Equal(res1, res2) {
-					t.Fatalf("Divergent Seal outputs: %x != %x", res1, res2)
+					t.Fatalf("Divergent Seal outputs: %x != %x\n%s", res1, res2,
+						replayDivergence(operations))
 				}
 			default:
 				panic(fmt.Sprintf("unknown operation type: %v", opType))
@@ -96,7 +127,8 @@ func FuzzProtocolDivergence(f *testing.F) {
 
 		final1, final2 := p1.Derive("final", nil, 8), p2.Derive("final", nil, 8)
 		if !bytes.Equal(final1, final2) {
-			t.Fatalf("Divergent final states: %x != %x", final1, final2)
+			t.Fatalf("Divergent final states: %x != %x\n%s", final1, final2,
+				replayDivergence(operations))
 		}
 	})
 }
@@ -238,6 +270,33 @@ func FuzzProtocolReversibility(f *testing.F) {
 	})
 }
 
+// replayDivergence replays the operations on two recorded protocols and returns the difference between their
+// recordings. It is only called once a divergence has been found, so the fuzz loop itself runs on plain protocols.
+func replayDivergence(operations []operation) string {
+	var r1, r2 lockstitch.Recorder
+	p1 := lockstitch.NewRecordedProtocol("divergence", &r1)
+	p2 := lockstitch.NewRecordedProtocol("divergence", &r2)
+
+	for _, op := range operations {
+		for _, p := range []*lockstitch.Protocol{p1, p2} {
+			switch op.opType {
+			case 0:
+				p.Mix(op.label, op.input)
+			case 1:
+				p.Derive(op.label, nil, op.n)
+			case 2:
+				p.Encrypt(op.label, nil, op.input)
+			case 3:
+				p.Seal(op.label, nil, op.input)
+			default:
+				panic(fmt.Sprintf("unknown operation type: %v", op.opType))
+			}
+		}
+	}
+
+	return lockstitch.Diff(r1.Operations(), r2.Operations())
+}
+
 type operation struct {
 	opType        byte
 	label         string
@@ -0,0 +1,494 @@
+// Package handshake implements Noise-style interactive handshakes using Lockstitch and crypto/ecdh.
+//
+// A handshake is described by a Pattern: a sequence of messages, alternating between the initiator and the responder,
+// each of which consists of a sequence of tokens. A single protocol acts as the handshake's symmetric state. Ephemeral
+// public keys are mixed into the protocol, static public keys and message payloads are sealed, and ECDH shared secrets
+// are mixed in as they become available. Because each operation depends on the entire transcript, tampering with any
+// message causes all subsequent operations to fail.
+//
+// Once the final message has been written or read, Split returns a pair of independent protocols for sending and
+// receiving transport messages.
+//
+// Unlike Noise, each message's payload is always sealed, even before any shared secrets have been established. A
+// payload sealed before any shared secrets have been mixed in is neither confidential nor authenticated.
+package handshake
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"errors"
+
+	"github.com/codahale/lockstitch-go"
+)
+
+// A Token is a step in a handshake message.
+type Token uint8
+
+const (
+	// TokenE sends an ephemeral public key in the clear.
+	TokenE Token = iota + 1
+
+	// TokenS sends a sealed static public key.
+	TokenS
+
+	// TokenEE mixes in the shared secret of the initiator's and responder's ephemeral keys.
+	TokenEE
+
+	// TokenES mixes in the shared secret of the initiator's ephemeral key and the responder's static key.
+	TokenES
+
+	// TokenSE mixes in the shared secret of the initiator's static key and the responder's ephemeral key.
+	TokenSE
+
+	// TokenSS mixes in the shared secret of the initiator's and responder's static keys.
+	TokenSS
+)
+
+// String returns the token's name, as used in Noise pattern notation.
+func (t Token) String() string {
+	switch t {
+	case TokenE:
+		return "e"
+	case TokenS:
+		return "s"
+	case TokenEE:
+		return "ee"
+	case TokenES:
+		return "es"
+	case TokenSE:
+		return "se"
+	case TokenSS:
+		return "ss"
+	default:
+		return "invalid"
+	}
+}
+
+// A Pattern describes the messages of a handshake.
+type Pattern struct {
+	// Name is the pattern's name, which is mixed into the protocol.
+	Name string
+
+	// InitiatorPreMessage and ResponderPreMessage are the tokens known to the other party before the handshake begins.
+	// Only TokenS is supported.
+	InitiatorPreMessage, ResponderPreMessage []Token
+
+	// Messages are the tokens of each handshake message. The initiator writes the first message and the parties
+	// alternate after that.
+	Messages [][]Token
+}
+
+//nolint:gochecknoglobals // these are constant patterns
+var (
+	// NN is an unauthenticated handshake:
+	//
+	//	-> e
+	//	<- e, ee
+	NN = Pattern{
+		Name:                "NN",
+		InitiatorPreMessage: nil,
+		ResponderPreMessage: nil,
+		Messages:            [][]Token{{TokenE}, {TokenE, TokenEE}},
+	}
+
+	// NK is a handshake in which the initiator knows the responder's static key in advance:
+	//
+	//	<- s
+	//	...
+	//	-> e, es
+	//	<- e, ee
+	NK = Pattern{
+		Name:                "NK",
+		InitiatorPreMessage: nil,
+		ResponderPreMessage: []Token{TokenS},
+		Messages:            [][]Token{{TokenE, TokenES}, {TokenE, TokenEE}},
+	}
+
+	// XX is a mutually authenticated handshake in which both parties transmit their static keys:
+	//
+	//	-> e
+	//	<- e, ee, s, es
+	//	-> s, se
+	XX = Pattern{
+		Name:                "XX",
+		InitiatorPreMessage: nil,
+		ResponderPreMessage: nil,
+		Messages: [][]Token{
+			{TokenE},
+			{TokenE, TokenEE, TokenS, TokenES},
+			{TokenS, TokenSE},
+		},
+	}
+
+	// IK is a mutually authenticated handshake in which the initiator knows the responder's static key in advance and
+	// transmits its own static key in the first message:
+	//
+	//	<- s
+	//	...
+	//	-> e, es, s, ss
+	//	<- e, ee, se
+	IK = Pattern{
+		Name:                "IK",
+		InitiatorPreMessage: nil,
+		ResponderPreMessage: []Token{TokenS},
+		Messages: [][]Token{
+			{TokenE, TokenES, TokenS, TokenSS},
+			{TokenE, TokenEE, TokenSE},
+		},
+	}
+)
+
+// Config configures one party of a handshake.
+type Config struct {
+	// Pattern is the handshake pattern.
+	Pattern Pattern
+
+	// Initiator is true if the party writes the first message.
+	Initiator bool
+
+	// Curve is the curve used for all keys. If nil, X25519 is used.
+	Curve ecdh.Curve
+
+	// Prologue is data which both parties must agree on, which is mixed into the protocol before the handshake begins.
+	Prologue []byte
+
+	// StaticKey is the party's static private key, if the pattern requires one.
+	StaticKey *ecdh.PrivateKey
+
+	// RemoteStaticKey is the other party's static public key, if the pattern requires it to be known in advance.
+	RemoteStaticKey *ecdh.PublicKey
+}
+
+var (
+	// ErrUnsupportedCurve is returned when a configuration uses a curve other than X25519 or P-256.
+	ErrUnsupportedCurve = errors.New("handshake: unsupported curve")
+
+	// ErrMismatchedCurves is returned when a configuration's keys use different curves.
+	ErrMismatchedCurves = errors.New("handshake: mismatched curves")
+
+	// ErrInvalidPattern is returned when a pattern contains invalid tokens.
+	ErrInvalidPattern = errors.New("handshake: invalid pattern")
+
+	// ErrMissingStaticKey is returned when a pattern requires a static key which was not configured.
+	ErrMissingStaticKey = errors.New("handshake: missing static key")
+
+	// ErrOutOfOrder is returned when a message is written or read out of turn, after the handshake is complete, or
+	// before the handshake is complete.
+	ErrOutOfOrder = errors.New("handshake: message out of order")
+)
+
+// A Handshake is one party's state of an interactive handshake.
+type Handshake struct {
+	p         *lockstitch.Protocol
+	pattern   Pattern
+	initiator bool
+	curve     ecdh.Curve
+	pubLen    int
+	s, e      *ecdh.PrivateKey
+	rs, re    *ecdh.PublicKey
+	msg       int
+	err       error
+}
+
+// New returns a new Handshake for the given configuration.
+func New(cfg Config) (*Handshake, error) {
+	curve := cfg.Curve
+	if curve == nil {
+		curve = ecdh.X25519()
+	}
+
+	name, pubLen, err := curveParams(curve)
+	if err != nil {
+		return nil, err
+	}
+
+	if (cfg.StaticKey != nil && cfg.StaticKey.Curve() != curve) ||
+		(cfg.RemoteStaticKey != nil && cfg.RemoteStaticKey.Curve() != curve) {
+		return nil, ErrMismatchedCurves
+	}
+
+	if err := checkPattern(cfg); err != nil {
+		return nil, err
+	}
+
+	h := &Handshake{
+		p:         lockstitch.NewProtocol("lockstitch-go.handshake"),
+		pattern:   cfg.Pattern,
+		initiator: cfg.Initiator,
+		curve:     curve,
+		pubLen:    pubLen,
+		s:         cfg.StaticKey,
+		e:         nil,
+		rs:        cfg.RemoteStaticKey,
+		re:        nil,
+		msg:       0,
+		err:       nil,
+	}
+
+	h.p.Mix("pattern", []byte(cfg.Pattern.Name))
+	h.p.Mix("curve", []byte(name))
+	h.p.Mix("prologue", cfg.Prologue)
+
+	// Mix in the pre-messages, the initiator's first.
+	var localS *ecdh.PublicKey
+	if h.s != nil {
+		localS = h.s.PublicKey()
+	}
+
+	initiatorS, responderS := h.rs, localS
+	if h.initiator {
+		initiatorS, responderS = localS, h.rs
+	}
+
+	if len(cfg.Pattern.InitiatorPreMessage) > 0 {
+		h.p.Mix("initiator s", initiatorS.Bytes())
+	}
+
+	if len(cfg.Pattern.ResponderPreMessage) > 0 {
+		h.p.Mix("responder s", responderS.Bytes())
+	}
+
+	return h, nil
+}
+
+// WriteMessage writes the next handshake message with the given payload, appends it to dst, and returns the resulting
+// slice. If the payload is sealed before any shared secrets have been established, it is not confidential.
+func (h *Handshake) WriteMessage(dst, payload []byte) ([]byte, error) {
+	if err := h.checkTurn(true); err != nil {
+		return nil, err
+	}
+
+	for _, token := range h.pattern.Messages[h.msg] {
+		switch token {
+		case TokenE:
+			e, err := h.curve.GenerateKey(rand.Reader)
+			if err != nil {
+				h.err = err
+				return nil, err
+			}
+			h.e = e
+
+			pub := e.PublicKey().Bytes()
+			h.p.Mix("e", pub)
+			dst = append(dst, pub...)
+		case TokenS:
+			dst = h.p.Seal("s", dst, h.s.PublicKey().Bytes())
+		case TokenEE, TokenES, TokenSE, TokenSS:
+			if err := h.mixDH(token); err != nil {
+				h.err = err
+				return nil, err
+			}
+		}
+	}
+
+	h.msg++
+
+	return h.p.Seal("payload", dst, payload), nil
+}
+
+// ReadMessage reads the next handshake message, appends its payload to dst, and returns the resulting slice. If the
+// message is invalid, it returns lockstitch.ErrInvalidCiphertext and the handshake cannot continue.
+func (h *Handshake) ReadMessage(dst, message []byte) ([]byte, error) {
+	if err := h.checkTurn(false); err != nil {
+		return nil, err
+	}
+
+	for _, token := range h.pattern.Messages[h.msg] {
+		var err error
+		switch token {
+		case TokenE:
+			if len(message) < h.pubLen {
+				err = lockstitch.ErrInvalidCiphertext
+				break
+			}
+
+			h.re, err = h.curve.NewPublicKey(message[:h.pubLen])
+			h.p.Mix("e", message[:h.pubLen])
+			message = message[h.pubLen:]
+		case TokenS:
+			if len(message) < h.pubLen+lockstitch.TagLen {
+				err = lockstitch.ErrInvalidCiphertext
+				break
+			}
+
+			var pub []byte
+			pub, err = h.p.Open("s", nil, message[:h.pubLen+lockstitch.TagLen])
+			if err == nil {
+				h.rs, err = h.curve.NewPublicKey(pub)
+			}
+			message = message[h.pubLen+lockstitch.TagLen:]
+		case TokenEE, TokenES, TokenSE, TokenSS:
+			err = h.mixDH(token)
+		}
+
+		if err != nil {
+			h.err = lockstitch.ErrInvalidCiphertext
+			return nil, h.err
+		}
+	}
+
+	if len(message) < lockstitch.TagLen {
+		h.err = lockstitch.ErrInvalidCiphertext
+		return nil, h.err
+	}
+
+	payload, err := h.p.Open("payload", dst, message)
+	if err != nil {
+		h.err = err
+		return nil, err
+	}
+
+	h.msg++
+
+	return payload, nil
+}
+
+// IsComplete returns true if all handshake messages have been written or read.
+func (h *Handshake) IsComplete() bool {
+	return h.msg == len(h.pattern.Messages)
+}
+
+// RemoteStaticKey returns the other party's static public key, if known.
+func (h *Handshake) RemoteStaticKey() *ecdh.PublicKey {
+	return h.rs
+}
+
+// Split returns a pair of independent protocols for sending transport messages to and receiving transport messages
+// from the other party. It returns ErrOutOfOrder if the handshake is not complete. After Split returns, the
+// Handshake can no longer be used.
+func (h *Handshake) Split() (send, recv *lockstitch.Protocol, err error) {
+	if h.err != nil {
+		return nil, nil, h.err
+	}
+
+	if !h.IsComplete() {
+		return nil, nil, ErrOutOfOrder
+	}
+
+	i2r := h.p.Clone()
+	i2r.Mix("initiator to responder", nil)
+
+	r2i := h.p
+	r2i.Mix("responder to initiator", nil)
+
+	h.p, h.e, h.err = nil, nil, ErrOutOfOrder
+
+	if h.initiator {
+		return i2r, r2i, nil
+	}
+
+	return r2i, i2r, nil
+}
+
+// checkTurn returns an error if the handshake has failed, or if it is not the party's turn to write or read.
+func (h *Handshake) checkTurn(write bool) error {
+	if h.err != nil {
+		return h.err
+	}
+
+	if h.IsComplete() || (h.msg%2 == 0) != (h.initiator == write) {
+		return ErrOutOfOrder
+	}
+
+	return nil
+}
+
+// mixDH calculates the ECDH shared secret for the given token and mixes it into the protocol.
+func (h *Handshake) mixDH(token Token) error {
+	var local *ecdh.PrivateKey
+	var remote *ecdh.PublicKey
+
+	switch token {
+	case TokenEE:
+		local, remote = h.e, h.re
+	case TokenES:
+		if h.initiator {
+			local, remote = h.e, h.rs
+		} else {
+			local, remote = h.s, h.re
+		}
+	case TokenSE:
+		if h.initiator {
+			local, remote = h.s, h.re
+		} else {
+			local, remote = h.e, h.rs
+		}
+	case TokenSS:
+		local, remote = h.s, h.rs
+	default:
+		return ErrInvalidPattern
+	}
+
+	if local == nil || remote == nil {
+		return ErrInvalidPattern
+	}
+
+	ss, err := local.ECDH(remote)
+	if err != nil {
+		return err
+	}
+
+	h.p.Mix(token.String(), ss)
+
+	return nil
+}
+
+// checkPattern returns an error if the pattern is invalid or if the configuration lacks a key it requires.
+func checkPattern(cfg Config) error {
+	for _, pre := range [][]Token{cfg.Pattern.InitiatorPreMessage, cfg.Pattern.ResponderPreMessage} {
+		if len(pre) > 1 || (len(pre) == 1 && pre[0] != TokenS) {
+			return ErrInvalidPattern
+		}
+	}
+
+	localPre, remotePre := cfg.Pattern.ResponderPreMessage, cfg.Pattern.InitiatorPreMessage
+	if cfg.Initiator {
+		localPre, remotePre = remotePre, localPre
+	}
+
+	if len(remotePre) > 0 && cfg.RemoteStaticKey == nil {
+		return ErrMissingStaticKey
+	}
+
+	needStatic := len(localPre) > 0
+	for i, msg := range cfg.Pattern.Messages {
+		local := (i%2 == 0) == cfg.Initiator
+		for _, token := range msg {
+			switch token {
+			case TokenE, TokenEE:
+			case TokenS:
+				needStatic = needStatic || local
+			case TokenES:
+				needStatic = needStatic || !cfg.Initiator
+			case TokenSE:
+				needStatic = needStatic || cfg.Initiator
+			case TokenSS:
+				needStatic = true
+			default:
+				return ErrInvalidPattern
+			}
+		}
+	}
+
+	if needStatic && cfg.StaticKey == nil {
+		return ErrMissingStaticKey
+	}
+
+	return nil
+}
+
+func curveParams(curve ecdh.Curve) (name string, pubLen int, err error) {
+	switch curve {
+	case ecdh.X25519():
+		return "X25519", x25519PublicKeyLen, nil
+	case ecdh.P256():
+		return "P-256", p256PublicKeyLen, nil
+	default:
+		return "", 0, ErrUnsupportedCurve
+	}
+}
+
+const (
+	x25519PublicKeyLen = 32 // The length, in bytes, of an X25519 public key.
+	p256PublicKeyLen   = 65 // The length, in bytes, of an uncompressed P-256 public key.
+)
@@ -0,0 +1,446 @@
+package handshake_test
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/codahale/lockstitch-go"
+	"github.com/codahale/lockstitch-go/handshake"
+)
+
+func TestHandshake(t *testing.T) {
+	t.Parallel()
+
+	for _, curve := range []ecdh.Curve{ecdh.X25519(), ecdh.P256()} {
+		for _, pattern := range []handshake.Pattern{handshake.NN, handshake.NK, handshake.XX, handshake.IK} {
+			t.Run(fmt.Sprintf("%s/%s", pattern.Name, curve), func(t *testing.T) {
+				t.Parallel()
+
+				initiatorKey, responderKey := generateKey(t, curve), generateKey(t, curve)
+				initiator, responder := configs(pattern, curve, initiatorKey, responderKey)
+
+				i, r := run(t, initiator, responder)
+
+				// Check that each side's sending protocol matches the other side's receiving protocol.
+				for _, pair := range [][2]*lockstitch.Protocol{{i.send, r.recv}, {r.send, i.recv}} {
+					ciphertext := pair[0].Seal("message", nil, []byte("this is an example"))
+					plaintext, err := pair[1].Open("message", nil, ciphertext)
+					if err != nil {
+						t.Fatal(err)
+					}
+
+					if got, want := string(plaintext), "this is an example"; got != want {
+						t.Errorf("Open = %q, want = %q", got, want)
+					}
+				}
+
+				// Check that the two directions are independent.
+				if bytes.Equal(i.send.Derive("check", nil, 16), i.recv.Derive("check", nil, 16)) {
+					t.Error("send and receive protocols are not independent")
+				}
+
+				// Check that each side's payloads were received.
+				for n, payload := range r.payloads {
+					if got, want := string(payload), fmt.Sprintf("initiator payload %d", n); got != want {
+						t.Errorf("responder payload %d = %q, want = %q", n, got, want)
+					}
+				}
+
+				for n, payload := range i.payloads {
+					if got, want := string(payload), fmt.Sprintf("responder payload %d", n); got != want {
+						t.Errorf("initiator payload %d = %q, want = %q", n, got, want)
+					}
+				}
+
+				// Check that authenticated patterns exchange static keys.
+				if pattern.Name == "XX" || pattern.Name == "IK" {
+					if got := r.remoteStatic; got == nil || !got.Equal(initiatorKey.PublicKey()) {
+						t.Error("responder did not learn initiator's static key")
+					}
+				}
+
+				if pattern.Name != "NN" {
+					if got := i.remoteStatic; got == nil || !got.Equal(responderKey.PublicKey()) {
+						t.Error("initiator did not learn responder's static key")
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestHandshake_WrongResponderKey(t *testing.T) {
+	t.Parallel()
+
+	for _, pattern := range []handshake.Pattern{handshake.NK, handshake.IK} {
+		t.Run(pattern.Name, func(t *testing.T) {
+			t.Parallel()
+
+			curve := ecdh.X25519()
+			initiator, responder := configs(pattern, curve, generateKey(t, curve), generateKey(t, curve))
+			initiator.RemoteStaticKey = generateKey(t, curve).PublicKey()
+
+			i, err := handshake.New(initiator)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r, err := handshake.New(responder)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			msg, err := i.WriteMessage(nil, []byte("payload"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := r.ReadMessage(nil, msg); !errors.Is(err, lockstitch.ErrInvalidCiphertext) {
+				t.Errorf("ReadMessage = %v, want = %v", err, lockstitch.ErrInvalidCiphertext)
+			}
+		})
+	}
+}
+
+func TestHandshake_Tampering(t *testing.T) {
+	t.Parallel()
+
+	curve := ecdh.X25519()
+	initiator, responder := configs(handshake.XX, curve, generateKey(t, curve), generateKey(t, curve))
+
+	i, err := handshake.New(initiator)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg, err := i.WriteMessage(nil, []byte("payload"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for n := range msg {
+		r, err := handshake.New(responder)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		modified := bytes.Clone(msg)
+		modified[n] ^= 1
+		if _, err := r.ReadMessage(nil, modified); !errors.Is(err, lockstitch.ErrInvalidCiphertext) {
+			t.Errorf("ReadMessage(message with byte %d modified) = %v, want = %v", n, err, lockstitch.ErrInvalidCiphertext)
+		}
+
+		// A failed handshake cannot continue.
+		if _, err := r.WriteMessage(nil, nil); !errors.Is(err, lockstitch.ErrInvalidCiphertext) {
+			t.Errorf("WriteMessage after failure = %v, want = %v", err, lockstitch.ErrInvalidCiphertext)
+		}
+	}
+}
+
+func TestHandshake_Truncated(t *testing.T) {
+	t.Parallel()
+
+	curve := ecdh.X25519()
+	initiatorKey, responderKey := generateKey(t, curve), generateKey(t, curve)
+
+	for _, pattern := range []handshake.Pattern{handshake.NN, handshake.NK, handshake.XX, handshake.IK} {
+		t.Run(pattern.Name, func(t *testing.T) {
+			t.Parallel()
+
+			initiator, responder := configs(pattern, curve, initiatorKey, responderKey)
+
+			for k := range pattern.Messages {
+				// Find the length of message k.
+				_, msg := advance(t, initiator, responder, k)
+
+				for n := range len(msg) {
+					r, msg := advance(t, initiator, responder, k)
+					if _, err := r.ReadMessage(nil, msg[:n]); !errors.Is(err, lockstitch.ErrInvalidCiphertext) {
+						t.Errorf("ReadMessage(message %d truncated to %d bytes) = %v, want = %v", k, n, err,
+							lockstitch.ErrInvalidCiphertext)
+					}
+
+					// A failed handshake cannot continue.
+					if _, err := r.WriteMessage(nil, nil); !errors.Is(err, lockstitch.ErrInvalidCiphertext) {
+						t.Errorf("WriteMessage after failure = %v, want = %v", err, lockstitch.ErrInvalidCiphertext)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestHandshake_Prologue(t *testing.T) {
+	t.Parallel()
+
+	curve := ecdh.X25519()
+	initiator, responder := configs(handshake.NN, curve, nil, nil)
+	initiator.Prologue = []byte("version 1")
+	responder.Prologue = []byte("version 2")
+
+	i, err := handshake.New(initiator)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := handshake.New(responder)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg, err := i.WriteMessage(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := r.ReadMessage(nil, msg); !errors.Is(err, lockstitch.ErrInvalidCiphertext) {
+		t.Errorf("ReadMessage(mismatched prologue) = %v, want = %v", err, lockstitch.ErrInvalidCiphertext)
+	}
+}
+
+func TestHandshake_OutOfOrder(t *testing.T) {
+	t.Parallel()
+
+	curve := ecdh.X25519()
+	initiator, responder := configs(handshake.NN, curve, nil, nil)
+
+	i, err := handshake.New(initiator)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := handshake.New(responder)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := r.WriteMessage(nil, nil); !errors.Is(err, handshake.ErrOutOfOrder) {
+		t.Errorf("responder WriteMessage = %v, want = %v", err, handshake.ErrOutOfOrder)
+	}
+
+	if _, err := i.ReadMessage(nil, nil); !errors.Is(err, handshake.ErrOutOfOrder) {
+		t.Errorf("initiator ReadMessage = %v, want = %v", err, handshake.ErrOutOfOrder)
+	}
+
+	if _, _, err := i.Split(); !errors.Is(err, handshake.ErrOutOfOrder) {
+		t.Errorf("Split = %v, want = %v", err, handshake.ErrOutOfOrder)
+	}
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	curve := ecdh.X25519()
+
+	for _, tc := range []struct {
+		name string
+		cfg  handshake.Config
+		err  error
+	}{
+		{
+			name: "missing static key",
+			cfg:  handshake.Config{Pattern: handshake.XX, Initiator: true},
+			err:  handshake.ErrMissingStaticKey,
+		},
+		{
+			name: "missing remote static key",
+			cfg:  handshake.Config{Pattern: handshake.NK, Initiator: true},
+			err:  handshake.ErrMissingStaticKey,
+		},
+		{
+			name: "mismatched curves",
+			cfg:  handshake.Config{Pattern: handshake.XX, Curve: ecdh.P256(), StaticKey: generateKey(t, curve)},
+			err:  handshake.ErrMismatchedCurves,
+		},
+		{
+			name: "unsupported curve",
+			cfg:  handshake.Config{Pattern: handshake.NN, Curve: ecdh.P384()},
+			err:  handshake.ErrUnsupportedCurve,
+		},
+		{
+			name: "invalid pattern",
+			cfg:  handshake.Config{Pattern: handshake.Pattern{Name: "bad", Messages: [][]handshake.Token{{0}}}},
+			err:  handshake.ErrInvalidPattern,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if _, err := handshake.New(tc.cfg); !errors.Is(err, tc.err) {
+				t.Errorf("New = %v, want = %v", err, tc.err)
+			}
+		})
+	}
+}
+
+type result struct {
+	send, recv   *lockstitch.Protocol
+	payloads     [][]byte
+	remoteStatic *ecdh.PublicKey
+}
+
+// advance runs a handshake in memory until message k has been written, and returns the party which should read it
+// and the message.
+func advance(t *testing.T, initiator, responder handshake.Config, k int) (*handshake.Handshake, []byte) {
+	t.Helper()
+
+	i, err := handshake.New(initiator)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := handshake.New(responder)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for n := 0; ; n++ {
+		writer, reader := i, r
+		if n%2 == 1 {
+			writer, reader = r, i
+		}
+
+		msg, err := writer.WriteMessage(nil, []byte("payload"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if n == k {
+			return reader, msg
+		}
+
+		if _, err := reader.ReadMessage(nil, msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// configs returns initiator and responder configurations for the given pattern.
+func configs(
+	pattern handshake.Pattern, curve ecdh.Curve, initiatorKey, responderKey *ecdh.PrivateKey,
+) (initiator, responder handshake.Config) {
+	initiator = handshake.Config{Pattern: pattern, Initiator: true, Curve: curve}
+	responder = handshake.Config{Pattern: pattern, Initiator: false, Curve: curve}
+
+	if pattern.Name == "XX" || pattern.Name == "IK" {
+		initiator.StaticKey = initiatorKey
+	}
+
+	if pattern.Name != "NN" {
+		responder.StaticKey = responderKey
+	}
+
+	if len(pattern.ResponderPreMessage) > 0 {
+		initiator.RemoteStaticKey = responderKey.PublicKey()
+	}
+
+	return initiator, responder
+}
+
+// run runs both sides of a handshake over an in-memory connection.
+func run(t *testing.T, initiator, responder handshake.Config) (i, r result) {
+	t.Helper()
+
+	a, b := net.Pipe()
+	t.Cleanup(func() {
+		_ = a.Close()
+		_ = b.Close()
+	})
+
+	errs := make(chan error, 1)
+	go func() {
+		var err error
+		r, err = runParty(b, responder, "responder")
+		errs <- err
+	}()
+
+	i, err := runParty(a, initiator, "initiator")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := <-errs; err != nil {
+		t.Fatal(err)
+	}
+
+	return i, r
+}
+
+func runParty(conn net.Conn, cfg handshake.Config, name string) (result, error) {
+	var res result
+
+	h, err := handshake.New(cfg)
+	if err != nil {
+		return res, err
+	}
+
+	sent := 0
+	for n := 0; !h.IsComplete(); n++ {
+		if (n%2 == 0) == cfg.Initiator {
+			msg, err := h.WriteMessage(nil, fmt.Appendf(nil, "%s payload %d", name, sent))
+			if err != nil {
+				return res, err
+			}
+
+			if err := writeFrame(conn, msg); err != nil {
+				return res, err
+			}
+			sent++
+		} else {
+			msg, err := readFrame(conn)
+			if err != nil {
+				return res, err
+			}
+
+			payload, err := h.ReadMessage(nil, msg)
+			if err != nil {
+				return res, err
+			}
+			res.payloads = append(res.payloads, payload)
+		}
+	}
+
+	res.remoteStatic = h.RemoteStaticKey()
+	res.send, res.recv, err = h.Split()
+
+	return res, err
+}
+
+func writeFrame(w io.Writer, msg []byte) error {
+	_, err := w.Write(binary.BigEndian.AppendUint16(nil, uint16(len(msg))))
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(msg)
+	return err
+}
+
+func readFrame(r io.Reader) ([]byte, error) {
+	var n [2]byte
+	if _, err := io.ReadFull(r, n[:]); err != nil {
+		return nil, err
+	}
+
+	msg := make([]byte, binary.BigEndian.Uint16(n[:]))
+	_, err := io.ReadFull(r, msg)
+	return msg, err
+}
+
+func generateKey(tb testing.TB, curve ecdh.Curve) *ecdh.PrivateKey {
+	tb.Helper()
+
+	k, err := curve.GenerateKey(rand.Reader)
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	return k
+}
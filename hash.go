@@ -0,0 +1,189 @@
+package lockstitch
+
+import (
+	"encoding"
+	"encoding/binary"
+	"errors"
+	"hash"
+)
+
+// A Hash calculates a message digest using a protocol. It implements hash.Hash, hash.Cloner,
+// encoding.BinaryMarshaler, encoding.BinaryAppender, and encoding.BinaryUnmarshaler.
+//
+// A Hash is equivalent to initializing a protocol with a domain separation string, mixing the message into the protocol
+// with a MixWriter using the label "message", and deriving the digest using the label "digest".
+type Hash struct {
+	domain      string
+	init        *Protocol
+	p           *Protocol
+	w           *MixWriter
+	inputLabel  string
+	outputLabel string
+	size        int
+}
+
+// NewHash returns a Hash which calculates digests of size bytes using the given domain separation string.
+//
+// NewHash panics if size is not positive or greater than 64GiB.
+func NewHash(domain string, size int) *Hash {
+	return newHash(domain, NewProtocol(domain), "message", "digest", size)
+}
+
+// newHash returns a Hash which mixes the message into a clone of the given protocol, which was initialized with the
+// given domain separation string, using inputLabel and derives a digest of size bytes using outputLabel.
+func newHash(domain string, init *Protocol, inputLabel, outputLabel string, size int) *Hash {
+	if size <= 0 || uint64(size) > maxDeriveLen {
+		panic("invalid argument to NewHash: size must be positive and <= 64GiB")
+	}
+
+	h := &Hash{
+		domain:      domain,
+		init:        init,
+		p:           nil,
+		w:           nil,
+		inputLabel:  inputLabel,
+		outputLabel: outputLabel,
+		size:        size,
+	}
+	h.Reset()
+
+	return h
+}
+
+// Write mixes more data into the running hash. It never returns an error.
+func (h *Hash) Write(b []byte) (int, error) {
+	return h.w.Write(b)
+}
+
+// Sum appends the current digest to b and returns the resulting slice. It does not change the underlying hash state.
+func (h *Hash) Sum(b []byte) []byte {
+	p, w := h.clone()
+	_ = w.Close()
+	return p.Derive(h.outputLabel, b, h.size)
+}
+
+// Reset resets the Hash to its initial state.
+func (h *Hash) Reset() {
+	h.p = h.init.Clone()
+	h.w = h.p.MixWriter(h.inputLabel)
+}
+
+// Size returns the number of bytes Sum will return.
+func (h *Hash) Size() int {
+	return h.size
+}
+
+// BlockSize returns the size of the chunks into which the message is split. Writes which are multiples of the block
+// size are not buffered.
+func (h *Hash) BlockSize() int {
+	return mixChunkLen
+}
+
+// Clone returns a separate Hash instance with the same state as h.
+func (h *Hash) Clone() (hash.Cloner, error) {
+	p, w := h.clone()
+	return &Hash{
+		domain:      h.domain,
+		init:        h.init,
+		p:           p,
+		w:           w,
+		inputLabel:  h.inputLabel,
+		outputLabel: h.outputLabel,
+		size:        h.size,
+	}, nil
+}
+
+// MarshalBinary returns the hash's current state, including its domain separation string and size.
+func (h *Hash) MarshalBinary() ([]byte, error) {
+	return h.AppendBinary(make([]byte, 0, len(hashMagic)+4+len(h.domain)+8+4+marshaledProtocolLen+len(h.w.buf)))
+}
+
+// AppendBinary appends the hash's current state, including its domain separation string and size, to b and returns the
+// resulting slice.
+func (h *Hash) AppendBinary(b []byte) ([]byte, error) {
+	state, err := h.p.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	b = append(b, hashMagic...)
+	b = binary.BigEndian.AppendUint32(b, uint32(len(h.domain))) //nolint:gosec // domains are always small
+	b = append(b, h.domain...)
+	b = binary.BigEndian.AppendUint64(b, uint64(h.size))
+	b = binary.BigEndian.AppendUint32(b, uint32(len(state))) //nolint:gosec // state is always small
+	b = append(b, state...)
+	b = append(b, h.w.buf...)
+
+	return b, nil
+}
+
+// UnmarshalBinary restores a state previously returned by MarshalBinary. It returns an error if the receiver was not
+// created with the same domain separation string and size as the Hash which produced the state.
+func (h *Hash) UnmarshalBinary(data []byte) error {
+	if len(data) < len(hashMagic)+4 || string(data[:len(hashMagic)]) != hashMagic {
+		return errInvalidHashState
+	}
+	data = data[len(hashMagic):]
+
+	// Check the domain separation string and size.
+	n := binary.BigEndian.Uint32(data)
+	data = data[4:]
+	if uint64(len(data)) < uint64(n)+8+4 || string(data[:n]) != h.domain {
+		return errInvalidHashState
+	}
+	data = data[n:]
+
+	if binary.BigEndian.Uint64(data) != uint64(h.size) {
+		return errInvalidHashState
+	}
+	data = data[8:]
+
+	n = binary.BigEndian.Uint32(data)
+	data = data[4:]
+	if uint64(len(data)) < uint64(n) || len(data)-int(n) >= mixChunkLen {
+		return errInvalidHashState
+	}
+
+	var p Protocol
+	if err := p.UnmarshalBinary(data[:n]); err != nil {
+		return err
+	}
+
+	h.p = &p
+	h.w = &MixWriter{
+		p:      h.p,
+		label:  h.inputLabel,
+		buf:    append(make([]byte, 0, mixChunkLen), data[n:]...),
+		n:      0,
+		closed: false,
+	}
+
+	return nil
+}
+
+// clone returns a copy of the hash's protocol and its MixWriter.
+func (h *Hash) clone() (*Protocol, *MixWriter) {
+	p := h.p.Clone()
+	return p, &MixWriter{
+		p:      p,
+		label:  h.inputLabel,
+		buf:    append(make([]byte, 0, mixChunkLen), h.w.buf...),
+		n:      h.w.n,
+		closed: false,
+	}
+}
+
+var (
+	_ hash.Hash                  = (*Hash)(nil)
+	_ hash.Cloner                = (*Hash)(nil)
+	_ encoding.BinaryMarshaler   = (*Hash)(nil)
+	_ encoding.BinaryAppender    = (*Hash)(nil)
+	_ encoding.BinaryUnmarshaler = (*Hash)(nil)
+)
+
+var errInvalidHashState = errors.New("lockstitch: invalid hash state")
+
+const (
+	hashMagic            = "lockstitch hash\x01" // The prefix of a marshaled Hash state.
+	marshaledProtocolLen = 108                   // The length, in bytes, of a marshaled Protocol state.
+)
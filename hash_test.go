@@ -0,0 +1,116 @@
+package lockstitch_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"hash"
+	"io"
+	"testing"
+
+	"github.com/codahale/lockstitch-go"
+)
+
+func TestHash(t *testing.T) {
+	t.Parallel()
+
+	message := bytes.Repeat([]byte("this is an example"), 1000)
+
+	p := lockstitch.NewProtocol("com.example.md")
+	w := p.MixWriter("message")
+	_, _ = w.Write(message)
+	_ = w.Close()
+	want := p.Derive("digest", nil, 32)
+
+	h := lockstitch.NewHash("com.example.md", 32)
+	if _, err := io.Copy(h, bytes.NewReader(message)); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := h.Sum(nil); !bytes.Equal(got, want) {
+		t.Errorf("Sum = %x, want = %x", got, want)
+	}
+
+	if got := h.Sum(nil); !bytes.Equal(got, want) {
+		t.Errorf("second Sum = %x, want = %x", got, want)
+	}
+
+	if got, want := h.Size(), 32; got != want {
+		t.Errorf("Size = %d, want = %d", got, want)
+	}
+
+	if got, want := hex.EncodeToString(lockstitch.NewHash("com.example.md", 8).Sum(nil)), "f58b594df0d3def1"; got != want {
+		t.Errorf("Sum = %v, want = %v", got, want)
+	}
+}
+
+func TestHash_Reset(t *testing.T) {
+	t.Parallel()
+
+	h := lockstitch.NewHash("com.example.md", 32)
+	want := h.Sum(nil)
+
+	_, _ = h.Write([]byte("this is an example"))
+	h.Reset()
+
+	if got := h.Sum(nil); !bytes.Equal(got, want) {
+		t.Errorf("Sum = %x, want = %x", got, want)
+	}
+}
+
+func TestHash_Clone(t *testing.T) {
+	t.Parallel()
+
+	h1 := lockstitch.NewHash("com.example.md", 32)
+	_, _ = h1.Write([]byte("this is an example"))
+
+	c, err := h1.Clone()
+	if err != nil {
+		t.Fatal(err)
+	}
+	h2 := c.(hash.Hash) //nolint:errcheck // it's a Hash
+
+	if got, want := h2.Sum(nil), h1.Sum(nil); !bytes.Equal(got, want) {
+		t.Errorf("Sum = %x, want = %x", got, want)
+	}
+
+	_, _ = h2.Write([]byte("more"))
+	if got, want := h2.Sum(nil), h1.Sum(nil); bytes.Equal(got, want) {
+		t.Errorf("clone shares state with original: %x", got)
+	}
+}
+
+func TestHash_MarshalBinary(t *testing.T) {
+	t.Parallel()
+
+	h1 := lockstitch.NewHash("com.example.md", 32)
+	_, _ = h1.Write(bytes.Repeat([]byte("this is an example"), 500))
+
+	state, err := h1.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h2 := lockstitch.NewHash("com.example.md", 32)
+	if err := h2.UnmarshalBinary(state); err != nil {
+		t.Fatal(err)
+	}
+
+	_, _ = h1.Write([]byte("more"))
+	_, _ = h2.Write([]byte("more"))
+
+	if got, want := h2.Sum(nil), h1.Sum(nil); !bytes.Equal(got, want) {
+		t.Errorf("Sum = %x, want = %x", got, want)
+	}
+
+	if err := h2.UnmarshalBinary(state[:10]); err == nil {
+		t.Error("UnmarshalBinary(truncated state) did not return an error")
+	}
+
+	if err := lockstitch.NewHash("com.example.other", 32).UnmarshalBinary(state); err == nil {
+		t.Error("UnmarshalBinary(state with different domain) did not return an error")
+	}
+
+	if err := lockstitch.NewHash("com.example.md", 16).UnmarshalBinary(state); err == nil {
+		t.Error("UnmarshalBinary(state with different size) did not return an error")
+	}
+}
@@ -0,0 +1,201 @@
+// Package hpke implements an ECIES-style hybrid public-key encryption scheme using Lockstitch and either X25519 or
+// NIST P-256 via crypto/ecdh.
+//
+// A ciphertext consists of the sender's ephemeral public key (32 bytes for X25519, 65 bytes for P-256), followed by the
+// sealed plaintext and its authentication tag.
+//
+// Encrypt and Decrypt implement the unauthenticated scheme from the design document: anyone in possession of the
+// receiver's public key can create valid ciphertexts. AuthEncrypt and AuthDecrypt additionally mix in a static ECDH
+// shared secret between the sender and the receiver, which authenticates the sender to the receiver. The
+// authenticated scheme is outsider secure but not insider secure: an adversary in possession of the receiver's private
+// key can forge ciphertexts from arbitrary senders.
+package hpke
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"errors"
+
+	"github.com/codahale/lockstitch-go"
+)
+
+var (
+	// ErrUnsupportedCurve is returned when a key uses a curve other than X25519 or P-256.
+	ErrUnsupportedCurve = errors.New("hpke: unsupported curve")
+
+	// ErrMismatchedCurves is returned when the keys passed to an operation use different curves.
+	ErrMismatchedCurves = errors.New("hpke: mismatched curves")
+
+	errNilSender = errors.New("hpke: nil sender")
+)
+
+// Encrypt encrypts the plaintext for the given receiver's public key and returns the ciphertext.
+func Encrypt(receiver *ecdh.PublicKey, plaintext []byte) ([]byte, error) {
+	return encrypt(nil, receiver, plaintext)
+}
+
+// Decrypt decrypts the ciphertext using the given receiver's private key. If the ciphertext is invalid, it returns
+// lockstitch.ErrInvalidCiphertext.
+func Decrypt(receiver *ecdh.PrivateKey, ciphertext []byte) ([]byte, error) {
+	return decrypt(receiver, nil, ciphertext)
+}
+
+// AuthEncrypt encrypts the plaintext for the given receiver's public key, authenticating it with the given sender's
+// private key, and returns the ciphertext.
+func AuthEncrypt(sender *ecdh.PrivateKey, receiver *ecdh.PublicKey, plaintext []byte) ([]byte, error) {
+	if sender == nil {
+		return nil, errNilSender
+	}
+
+	return encrypt(sender, receiver, plaintext)
+}
+
+// AuthDecrypt decrypts the ciphertext using the given receiver's private key, verifying that it was encrypted by the
+// given sender. If the ciphertext is invalid or was not encrypted by the sender, it returns
+// lockstitch.ErrInvalidCiphertext.
+func AuthDecrypt(receiver *ecdh.PrivateKey, sender *ecdh.PublicKey, ciphertext []byte) ([]byte, error) {
+	if sender == nil {
+		return nil, errNilSender
+	}
+
+	return decrypt(receiver, sender, ciphertext)
+}
+
+func encrypt(sender *ecdh.PrivateKey, receiver *ecdh.PublicKey, plaintext []byte) ([]byte, error) {
+	curve := receiver.Curve()
+	if sender != nil && sender.Curve() != curve {
+		return nil, ErrMismatchedCurves
+	}
+
+	// Generate an ephemeral key pair.
+	ephemeral, err := curve.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	// Calculate the ephemeral ECDH shared secret.
+	ss, err := ephemeral.ECDH(receiver)
+	if err != nil {
+		return nil, err
+	}
+
+	// Calculate the static ECDH shared secret, if any.
+	var staticSS []byte
+	var senderPub *ecdh.PublicKey
+	if sender != nil {
+		staticSS, err = sender.ECDH(receiver)
+		if err != nil {
+			return nil, err
+		}
+		senderPub = sender.PublicKey()
+	}
+
+	p, err := newProtocol(receiver, senderPub, ephemeral.PublicKey(), ss, staticSS)
+	if err != nil {
+		return nil, err
+	}
+
+	// Seal the plaintext, appending it to the ephemeral public key.
+	out := ephemeral.PublicKey().Bytes()
+	return p.Seal("message", out, plaintext), nil
+}
+
+func decrypt(receiver *ecdh.PrivateKey, sender *ecdh.PublicKey, ciphertext []byte) ([]byte, error) {
+	curve := receiver.Curve()
+	if sender != nil && sender.Curve() != curve {
+		return nil, ErrMismatchedCurves
+	}
+
+	pubLen, err := publicKeyLen(curve)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ciphertext) < pubLen+lockstitch.TagLen {
+		return nil, lockstitch.ErrInvalidCiphertext
+	}
+
+	// Parse the ephemeral public key.
+	ephemeral, err := curve.NewPublicKey(ciphertext[:pubLen])
+	if err != nil {
+		return nil, lockstitch.ErrInvalidCiphertext
+	}
+
+	// Calculate the ephemeral ECDH shared secret.
+	ss, err := receiver.ECDH(ephemeral)
+	if err != nil {
+		return nil, lockstitch.ErrInvalidCiphertext
+	}
+
+	// Calculate the static ECDH shared secret, if any.
+	var staticSS []byte
+	if sender != nil {
+		staticSS, err = receiver.ECDH(sender)
+		if err != nil {
+			return nil, lockstitch.ErrInvalidCiphertext
+		}
+	}
+
+	p, err := newProtocol(receiver.PublicKey(), sender, ephemeral, ss, staticSS)
+	if err != nil {
+		return nil, err
+	}
+
+	// Open the ciphertext.
+	return p.Open("message", nil, ciphertext[pubLen:])
+}
+
+// newProtocol returns a protocol with the curve, public keys, and shared secrets mixed in. If sender is nil, the
+// protocol is unauthenticated.
+func newProtocol(receiver, sender, ephemeral *ecdh.PublicKey, ss, staticSS []byte) (*lockstitch.Protocol, error) {
+	name, err := curveName(receiver.Curve())
+	if err != nil {
+		return nil, err
+	}
+
+	domain := "lockstitch-go.hpke"
+	if sender != nil {
+		domain = "lockstitch-go.hpke.auth"
+	}
+
+	p := lockstitch.NewProtocol(domain)
+	p.Mix("curve", []byte(name))
+	p.Mix("receiver", receiver.Bytes())
+	if sender != nil {
+		p.Mix("sender", sender.Bytes())
+	}
+	p.Mix("ephemeral", ephemeral.Bytes())
+	p.Mix("ecdh", ss)
+	if sender != nil {
+		p.Mix("static ecdh", staticSS)
+	}
+
+	return p, nil
+}
+
+func curveName(curve ecdh.Curve) (string, error) {
+	switch curve {
+	case ecdh.X25519():
+		return "X25519", nil
+	case ecdh.P256():
+		return "P-256", nil
+	default:
+		return "", ErrUnsupportedCurve
+	}
+}
+
+func publicKeyLen(curve ecdh.Curve) (int, error) {
+	switch curve {
+	case ecdh.X25519():
+		return x25519PublicKeyLen, nil
+	case ecdh.P256():
+		return p256PublicKeyLen, nil
+	default:
+		return 0, ErrUnsupportedCurve
+	}
+}
+
+const (
+	x25519PublicKeyLen = 32 // The length, in bytes, of an X25519 public key.
+	p256PublicKeyLen   = 65 // The length, in bytes, of an uncompressed P-256 public key.
+)
@@ -0,0 +1,140 @@
+package hpke_test
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"github.com/codahale/lockstitch-go"
+	"github.com/codahale/lockstitch-go/hpke"
+)
+
+func TestEncrypt(t *testing.T) {
+	t.Parallel()
+
+	for _, curve := range curves {
+		t.Run(curve.name, func(t *testing.T) {
+			t.Parallel()
+
+			receiver := generateKey(t, curve.curve)
+			plaintext := []byte("this is an example")
+
+			ciphertext, err := hpke.Encrypt(receiver.PublicKey(), plaintext)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got, want := len(ciphertext), curve.pubLen+len(plaintext)+lockstitch.TagLen; got != want {
+				t.Errorf("len(ciphertext) = %d, want = %d", got, want)
+			}
+
+			got, err := hpke.Decrypt(receiver, ciphertext)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(got, plaintext) {
+				t.Errorf("Decrypt = %x, want = %x", got, plaintext)
+			}
+
+			for i := range ciphertext {
+				modified := bytes.Clone(ciphertext)
+				modified[i] ^= 1
+				if _, err := hpke.Decrypt(receiver, modified); err == nil {
+					t.Errorf("Decrypt(ciphertext with byte %d modified) did not return an error", i)
+				}
+			}
+
+			if _, err := hpke.Decrypt(generateKey(t, curve.curve), ciphertext); !errors.Is(err, lockstitch.ErrInvalidCiphertext) {
+				t.Errorf("Decrypt(wrong receiver) = %v, want = %v", err, lockstitch.ErrInvalidCiphertext)
+			}
+
+			if _, err := hpke.Decrypt(receiver, ciphertext[:curve.pubLen]); !errors.Is(err, lockstitch.ErrInvalidCiphertext) {
+				t.Errorf("Decrypt(short ciphertext) = %v, want = %v", err, lockstitch.ErrInvalidCiphertext)
+			}
+		})
+	}
+}
+
+func TestAuthEncrypt(t *testing.T) {
+	t.Parallel()
+
+	for _, curve := range curves {
+		t.Run(curve.name, func(t *testing.T) {
+			t.Parallel()
+
+			sender, receiver := generateKey(t, curve.curve), generateKey(t, curve.curve)
+			plaintext := []byte("this is an example")
+
+			ciphertext, err := hpke.AuthEncrypt(sender, receiver.PublicKey(), plaintext)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := hpke.AuthDecrypt(receiver, sender.PublicKey(), ciphertext)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(got, plaintext) {
+				t.Errorf("AuthDecrypt = %x, want = %x", got, plaintext)
+			}
+
+			other := generateKey(t, curve.curve)
+			if _, err := hpke.AuthDecrypt(receiver, other.PublicKey(), ciphertext); !errors.Is(err, lockstitch.ErrInvalidCiphertext) {
+				t.Errorf("AuthDecrypt(wrong sender) = %v, want = %v", err, lockstitch.ErrInvalidCiphertext)
+			}
+
+			if _, err := hpke.Decrypt(receiver, ciphertext); !errors.Is(err, lockstitch.ErrInvalidCiphertext) {
+				t.Errorf("Decrypt(authenticated ciphertext) = %v, want = %v", err, lockstitch.ErrInvalidCiphertext)
+			}
+		})
+	}
+}
+
+func TestCrossCurve(t *testing.T) {
+	t.Parallel()
+
+	x25519, p256 := generateKey(t, ecdh.X25519()), generateKey(t, ecdh.P256())
+
+	ciphertext, err := hpke.Encrypt(p256.PublicKey(), []byte("this is an example"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := hpke.Decrypt(x25519, ciphertext); !errors.Is(err, lockstitch.ErrInvalidCiphertext) {
+		t.Errorf("Decrypt(P-256 ciphertext, X25519 key) = %v, want = %v", err, lockstitch.ErrInvalidCiphertext)
+	}
+
+	if _, err := hpke.AuthEncrypt(x25519, p256.PublicKey(), nil); !errors.Is(err, hpke.ErrMismatchedCurves) {
+		t.Errorf("AuthEncrypt(X25519 sender, P-256 receiver) = %v, want = %v", err, hpke.ErrMismatchedCurves)
+	}
+
+	p384 := generateKey(t, ecdh.P384())
+	if _, err := hpke.Encrypt(p384.PublicKey(), nil); !errors.Is(err, hpke.ErrUnsupportedCurve) {
+		t.Errorf("Encrypt(P-384 receiver) = %v, want = %v", err, hpke.ErrUnsupportedCurve)
+	}
+}
+
+func generateKey(tb testing.TB, curve ecdh.Curve) *ecdh.PrivateKey {
+	tb.Helper()
+
+	k, err := curve.GenerateKey(rand.Reader)
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	return k
+}
+
+//nolint:gochecknoglobals // this is fine
+var curves = []struct {
+	name   string
+	curve  ecdh.Curve
+	pubLen int
+}{
+	{"X25519", ecdh.X25519(), 32},
+	{"P-256", ecdh.P256(), 65},
+}
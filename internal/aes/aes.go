@@ -29,6 +29,17 @@ func CTR(key, iv, dst, src []byte) {
 	cipher.NewCTR(block, iv).XORKeyStream(dst, src)
 }
 
+// NewCTR returns a cipher.Stream which encrypts or decrypts using AES-CTR with the given key and IV. Unlike CTR, it
+// allows for the keystream to be generated incrementally.
+func NewCTR(key, iv []byte) cipher.Stream {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		panic(err)
+	}
+
+	return cipher.NewCTR(block, iv)
+}
+
 func ctrSmall(block cipher.Block, iv, dst, src []byte) {
 	var ctrBuf, tmpBuf [BlockSize]byte
 	ctr, tmp := ctrBuf[:], tmpBuf[:]
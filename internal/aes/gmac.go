@@ -0,0 +1,159 @@
+package aes
+
+import (
+	"crypto/aes"
+	"encoding/binary"
+	"math/bits"
+)
+
+// A GMACWriter calculates an AES-GMAC authenticator incrementally. Its output is identical to that of GMAC for the
+// concatenation of all data written to it.
+//
+// The standard library does not support incremental GCM operations, so GMACWriter implements GHASH using the
+// constant-time carry-less multiplication from BearSSL's ctmul64 implementation, which multiplies integers with gaps
+// between their bits instead of looking up key-dependent tables. Unlike GMAC, it does not use hardware-accelerated
+// carry-less multiplication and is correspondingly slower.
+type GMACWriter struct {
+	h0, h1   uint64 // The low and high halves of the hash key H = E(K, 0^128).
+	h0r, h1r uint64 // The bit-reversed halves of H.
+	y0, y1   uint64 // The low and high halves of the running GHASH value.
+	mask     [BlockSize]byte
+	buf      [BlockSize]byte
+	nbuf     int
+	n        uint64
+}
+
+// NewGMACWriter returns a GMACWriter with the given key and nonce. The nonce must be 12 bytes long.
+func NewGMACWriter(key, nonce []byte) *GMACWriter {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		panic(err)
+	}
+
+	if len(nonce) != gcmNonceLen {
+		panic("aes: invalid GMAC nonce length")
+	}
+
+	g := &GMACWriter{} //nolint:exhaustruct // zero values are fine
+
+	// Calculate the hash key H = E(K, 0^128).
+	var h [BlockSize]byte
+	block.Encrypt(h[:], h[:])
+	g.h1, g.h0 = binary.BigEndian.Uint64(h[:8]), binary.BigEndian.Uint64(h[8:])
+	g.h1r, g.h0r = bits.Reverse64(g.h1), bits.Reverse64(g.h0)
+
+	// Calculate the tag mask E(K, J0), where J0 = nonce || 0^31 || 1.
+	copy(g.mask[:], nonce)
+	g.mask[BlockSize-1] = 1
+	block.Encrypt(g.mask[:], g.mask[:])
+
+	return g
+}
+
+// Write adds more data to the running authenticator. It never returns an error.
+func (g *GMACWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	g.n += uint64(n)
+
+	// Complete any partially buffered block.
+	if g.nbuf > 0 {
+		c := copy(g.buf[g.nbuf:], p)
+		g.nbuf += c
+		p = p[c:]
+		if g.nbuf < BlockSize {
+			return n, nil
+		}
+		g.updateBlock(g.buf[:])
+		g.nbuf = 0
+	}
+
+	// Process full blocks directly from the input.
+	for len(p) >= BlockSize {
+		g.updateBlock(p[:BlockSize])
+		p = p[BlockSize:]
+	}
+
+	// Buffer any remaining input.
+	g.nbuf = copy(g.buf[:], p)
+
+	return n, nil
+}
+
+// Sum appends the authenticator to b and returns the resulting slice. It does not change the underlying state.
+func (g *GMACWriter) Sum(b []byte) []byte {
+	y1, y0 := g.y1, g.y0
+
+	// Process any buffered data, zero-padded to a full block.
+	if g.nbuf > 0 {
+		var block [BlockSize]byte
+		copy(block[:], g.buf[:g.nbuf])
+		y1, y0 = g.mul(y1^binary.BigEndian.Uint64(block[:8]), y0^binary.BigEndian.Uint64(block[8:]))
+	}
+
+	// Process the lengths, in bits, of the authenticated data and the (empty) ciphertext.
+	y1, y0 = g.mul(y1^(g.n*8), y0)
+
+	var tag [BlockSize]byte
+	binary.BigEndian.PutUint64(tag[:8], y1)
+	binary.BigEndian.PutUint64(tag[8:], y0)
+	for i := range tag {
+		tag[i] ^= g.mask[i]
+	}
+
+	return append(b, tag[:]...)
+}
+
+func (g *GMACWriter) updateBlock(block []byte) {
+	g.y1, g.y0 = g.mul(g.y1^binary.BigEndian.Uint64(block[:8]), g.y0^binary.BigEndian.Uint64(block[8:]))
+}
+
+// mul returns the high and low halves of Y*H in GF(2¹²⁸), where y1 and y0 are the high and low halves of Y. It uses
+// Karatsuba multiplication over three 64x64-bit carry-less products, each of which is calculated twice (once on the
+// bit-reversed operands) to recover the high half of the product.
+func (g *GMACWriter) mul(y1, y0 uint64) (uint64, uint64) {
+	y0r, y1r := bits.Reverse64(y0), bits.Reverse64(y1)
+	y2, y2r := y0^y1, y0r^y1r
+	h2, h2r := g.h0^g.h1, g.h0r^g.h1r
+
+	// Calculate the low and high halves of the three products.
+	z0, z1, z2 := bmul64(y0, g.h0), bmul64(y1, g.h1), bmul64(y2, h2)
+	z0h, z1h, z2h := bmul64(y0r, g.h0r), bmul64(y1r, g.h1r), bmul64(y2r, h2r)
+	z2 ^= z0 ^ z1
+	z2h ^= z0h ^ z1h
+	z0h = bits.Reverse64(z0h) >> 1
+	z1h = bits.Reverse64(z1h) >> 1
+	z2h = bits.Reverse64(z2h) >> 1
+
+	// Combine them into a 256-bit product and shift it left by one bit, as GCM's bit order is reversed.
+	v0, v1, v2, v3 := z0, z0h^z2, z1^z2h, z1h
+	v3 = v3<<1 | v2>>63
+	v2 = v2<<1 | v1>>63
+	v1 = v1<<1 | v0>>63
+	v0 <<= 1
+
+	// Reduce the product modulo the GCM polynomial.
+	v2 ^= v0 ^ v0>>1 ^ v0>>2 ^ v0>>7
+	v1 ^= v0<<63 ^ v0<<62 ^ v0<<57
+	v3 ^= v1 ^ v1>>1 ^ v1>>2 ^ v1>>7
+	v2 ^= v1<<63 ^ v1<<62 ^ v1<<57
+
+	return v3, v2
+}
+
+// bmul64 returns the low 64 bits of the carry-less product of x and y. It masks each operand into four integers with
+// three zero bits between each of their bits, so that the carries of integer multiplication are absorbed by the gaps.
+func bmul64(x, y uint64) uint64 {
+	const m0, m1, m2, m3 = 0x1111111111111111, 0x2222222222222222, 0x4444444444444444, 0x8888888888888888
+
+	x0, x1, x2, x3 := x&m0, x&m1, x&m2, x&m3
+	y0, y1, y2, y3 := y&m0, y&m1, y&m2, y&m3
+	z0 := x0*y0 ^ x1*y3 ^ x2*y2 ^ x3*y1
+	z1 := x0*y1 ^ x1*y0 ^ x2*y3 ^ x3*y2
+	z2 := x0*y2 ^ x1*y1 ^ x2*y0 ^ x3*y3
+	z3 := x0*y3 ^ x1*y2 ^ x2*y1 ^ x3*y0
+
+	return z0&m0 | z1&m1 | z2&m2 | z3&m3
+}
+
+// gcmNonceLen is the length, in bytes, of an AES-GCM nonce.
+const gcmNonceLen = 12
@@ -0,0 +1,129 @@
+package aes_test
+
+import (
+	"bytes"
+	stdlibaes "crypto/aes"
+	"crypto/cipher"
+	"crypto/sha3"
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/codahale/lockstitch-go/internal/aes"
+)
+
+func TestGMACWriter(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		key, nonce, message, tag string
+	}{
+		// AES-GCM test vectors with empty plaintexts, from the GCM specification and NIST's CAVP.
+		{
+			key:     "00000000000000000000000000000000",
+			nonce:   "000000000000000000000000",
+			message: "",
+			tag:     "58e2fccefa7e3061367f1d57a4e7455a",
+		},
+		{
+			key:     "77be63708971c4e240d1cb79e8d77feb",
+			nonce:   "e0e00f19fed7ba0136a797f3",
+			message: "7a43ec1d9c0a5a78a0b16533a6213cab",
+			tag:     "209fcc8d3675ed938e9c7166709dd946",
+		},
+	} {
+		key, _ := hex.DecodeString(tc.key)
+		nonce, _ := hex.DecodeString(tc.nonce)
+		message, _ := hex.DecodeString(tc.message)
+
+		g := aes.NewGMACWriter(key, nonce)
+		_, _ = g.Write(message)
+
+		if got, want := hex.EncodeToString(g.Sum(nil)), tc.tag; got != want {
+			t.Errorf("Sum(%q) = %s, want = %s", tc.message, got, want)
+		}
+	}
+}
+
+func TestGMACWriter_ChunkBoundaries(t *testing.T) {
+	t.Parallel()
+
+	drbg := sha3.NewSHAKE128()
+	_, _ = drbg.Write([]byte("lockstitch gmac chunk boundaries"))
+
+	key := make([]byte, 16)
+	nonce := make([]byte, 12)
+	message := make([]byte, 4*aes.BlockSize+1)
+	_, _ = drbg.Read(key)
+	_, _ = drbg.Read(nonce)
+	_, _ = drbg.Read(message)
+
+	block, err := stdlibaes.NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for n := range len(message) + 1 {
+		want := gcm.Seal(nil, nonce, nil, message[:n])
+
+		for _, chunk := range []int{1, aes.BlockSize - 1, aes.BlockSize, aes.BlockSize + 1, 2 * aes.BlockSize} {
+			t.Run(fmt.Sprintf("%d bytes/%d byte chunks", n, chunk), func(t *testing.T) {
+				t.Parallel()
+
+				g := aes.NewGMACWriter(key, nonce)
+				for in := message[:n]; len(in) > 0; {
+					c := min(chunk, len(in))
+					_, _ = g.Write(in[:c])
+					in = in[c:]
+				}
+
+				if got := g.Sum(nil); !bytes.Equal(got, want) {
+					t.Errorf("Sum = %x, want = %x", got, want)
+				}
+			})
+		}
+	}
+}
+
+func FuzzGMACWriter(f *testing.F) {
+	drbg := sha3.NewSHAKE128()
+	_, _ = drbg.Write([]byte("lockstitch gmac implementation"))
+
+	for _, length := range lengths {
+		key := make([]byte, 16)
+		nonce := make([]byte, 12)
+		message := make([]byte, length.n+3)
+		_, _ = drbg.Read(key)
+		_, _ = drbg.Read(nonce)
+		_, _ = drbg.Read(message)
+		f.Add(key, nonce, message, uint(length.n/3))
+	}
+
+	f.Fuzz(func(t *testing.T, key, nonce, message []byte, split uint) {
+		if len(key) != 16 || len(nonce) != 12 {
+			t.SkipNow()
+		}
+
+		want := aes.GMAC(key, nonce, nil, message)
+
+		// Write the message in two pieces to exercise buffering.
+		g := aes.NewGMACWriter(key, nonce)
+		i := int(split % uint(len(message)+1))
+		_, _ = g.Write(message[:i])
+		_, _ = g.Write(message[i:])
+
+		if got := g.Sum(nil); !bytes.Equal(got, want) {
+			t.Fatalf("got %x want %x", got, want)
+		}
+
+		// Ensure Sum does not change the underlying state.
+		if got := g.Sum(nil); !bytes.Equal(got, want) {
+			t.Fatalf("second Sum: got %x want %x", got, want)
+		}
+	})
+}
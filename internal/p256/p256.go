@@ -0,0 +1,251 @@
+// Package p256 provides the NIST P-256 group operations needed for Schnorr-style signatures, which crypto/ecdh does not
+// expose, using only the standard library.
+//
+// Operations on secret values run in constant time: scalar arithmetic uses a constant-time implementation of
+// arithmetic modulo the order of the group (see Scalar), and ScalarBaseMult uses crypto/ecdh. The remaining point
+// operations (ScalarMult, Add, and ParseCompressedPoint) use math/big, which does not run in constant time, so they
+// must only be used with public values, as when verifying signatures.
+package p256
+
+import (
+	"crypto/ecdh"
+	"math/big"
+)
+
+const (
+	// ScalarSize is the size, in bytes, of an encoded scalar.
+	ScalarSize = 32
+
+	// CompressedPointSize is the size, in bytes, of a compressed point.
+	CompressedPointSize = 1 + ScalarSize
+)
+
+// A Point is a point on the P-256 curve in affine coordinates. The identity element is represented as (0, 0).
+type Point struct {
+	x, y *big.Int
+}
+
+// PointFromPublicKey returns the point corresponding to the given P-256 public key.
+func PointFromPublicKey(pub *ecdh.PublicKey) Point {
+	// crypto/ecdh has already validated the point, so the uncompressed encoding only needs to be split.
+	return pointFromUncompressed(pub.Bytes())
+}
+
+// ParseCompressedPoint parses a compressed point. It returns false if the encoding is invalid or the point is not on
+// the curve.
+func ParseCompressedPoint(b []byte) (Point, bool) {
+	if len(b) != CompressedPointSize || (b[0] != 2 && b[0] != 3) {
+		return identity(), false
+	}
+
+	x := new(big.Int).SetBytes(b[1:])
+	if x.Cmp(prime) >= 0 {
+		return identity(), false
+	}
+
+	// y^2 = x^3 - 3x + b
+	y2 := mulMod(mulMod(x, x), x)
+	y2 = subMod(y2, mulMod(x, big.NewInt(3)))
+	y2 = addMod(y2, curveB)
+
+	y := new(big.Int).ModSqrt(y2, prime)
+	if y == nil {
+		return identity(), false
+	}
+
+	// Choose the root with the encoded parity.
+	if y.Bit(0) != uint(b[0]&1) {
+		y.Sub(prime, y)
+	}
+
+	return Point{x: x, y: y}, true
+}
+
+// ScalarBaseMult returns [k]G. It runs in constant time.
+func ScalarBaseMult(k Scalar) Point {
+	// crypto/ecdh rejects the zero scalar, whose product is the identity element.
+	if k.IsZero() {
+		return identity()
+	}
+
+	priv, err := ecdh.P256().NewPrivateKey(k.Append(make([]byte, 0, ScalarSize)))
+	if err != nil {
+		panic(err)
+	}
+
+	return pointFromUncompressed(priv.PublicKey().Bytes())
+}
+
+// ScalarMult returns [k]P. It does not run in constant time and must only be used with public values.
+func (p Point) ScalarMult(k Scalar) Point {
+	q, j := jacobianIdentity(), p.jacobian()
+	for _, b := range k.Append(make([]byte, 0, ScalarSize)) {
+		for i := 7; i >= 0; i-- {
+			q = q.double()
+			if (b>>i)&1 == 1 {
+				q = q.add(j)
+			}
+		}
+	}
+
+	return q.affine()
+}
+
+// Add returns P+Q. It does not run in constant time and must only be used with public values.
+func (p Point) Add(q Point) Point {
+	return p.jacobian().add(q.jacobian()).affine()
+}
+
+// Neg returns -P.
+func (p Point) Neg() Point {
+	if p.IsIdentity() {
+		return p
+	}
+
+	return Point{x: p.x, y: new(big.Int).Sub(prime, p.y)}
+}
+
+// IsIdentity returns true if P is the identity element.
+func (p Point) IsIdentity() bool {
+	return p.x.Sign() == 0 && p.y.Sign() == 0
+}
+
+// Equal returns true if P and Q are the same point.
+func (p Point) Equal(q Point) bool {
+	return p.x.Cmp(q.x) == 0 && p.y.Cmp(q.y) == 0
+}
+
+// Compressed appends the compressed encoding of P to b and returns the resulting slice. P must not be the identity
+// element, which has no compressed encoding.
+func (p Point) Compressed(b []byte) []byte {
+	b = append(b, byte(2|p.y.Bit(0)))
+	return append(b, p.x.FillBytes(make([]byte, ScalarSize))...)
+}
+
+func (p Point) jacobian() jacobianPoint {
+	if p.IsIdentity() {
+		return jacobianIdentity()
+	}
+
+	return jacobianPoint{x: p.x, y: p.y, z: big.NewInt(1)}
+}
+
+// A jacobianPoint is the point (X/Z^2, Y/Z^3). The identity element has Z = 0.
+type jacobianPoint struct {
+	x, y, z *big.Int
+}
+
+func jacobianIdentity() jacobianPoint {
+	return jacobianPoint{x: big.NewInt(1), y: big.NewInt(1), z: new(big.Int)}
+}
+
+// double returns 2P using the dbl-2001-b formulas for a = -3.
+func (p jacobianPoint) double() jacobianPoint {
+	if p.z.Sign() == 0 {
+		return p
+	}
+
+	delta := mulMod(p.z, p.z)
+	gamma := mulMod(p.y, p.y)
+	beta := mulMod(p.x, gamma)
+	alpha := mulMod(big.NewInt(3), mulMod(subMod(p.x, delta), addMod(p.x, delta)))
+
+	// X3 = alpha^2 - 8*beta
+	beta4 := mulMod(big.NewInt(4), beta)
+	x3 := subMod(mulMod(alpha, alpha), addMod(beta4, beta4))
+
+	// Z3 = (Y+Z)^2 - gamma - delta
+	yz := addMod(p.y, p.z)
+	z3 := subMod(subMod(mulMod(yz, yz), gamma), delta)
+
+	// Y3 = alpha*(4*beta - X3) - 8*gamma^2
+	y3 := subMod(mulMod(alpha, subMod(beta4, x3)), mulMod(big.NewInt(8), mulMod(gamma, gamma)))
+
+	return jacobianPoint{x: x3, y: y3, z: z3}
+}
+
+// add returns P+Q using the add-2007-bl formulas.
+func (p jacobianPoint) add(q jacobianPoint) jacobianPoint {
+	if p.z.Sign() == 0 {
+		return q
+	}
+
+	if q.z.Sign() == 0 {
+		return p
+	}
+
+	z1z1, z2z2 := mulMod(p.z, p.z), mulMod(q.z, q.z)
+	u1, u2 := mulMod(p.x, z2z2), mulMod(q.x, z1z1)
+	s1, s2 := mulMod(mulMod(p.y, q.z), z2z2), mulMod(mulMod(q.y, p.z), z1z1)
+	h, r := subMod(u2, u1), subMod(s2, s1)
+
+	if h.Sign() == 0 {
+		if r.Sign() == 0 {
+			return p.double()
+		}
+
+		return jacobianIdentity()
+	}
+
+	h2 := addMod(h, h)
+	i := mulMod(h2, h2)
+	j := mulMod(h, i)
+	r = addMod(r, r)
+	v := mulMod(u1, i)
+
+	// X3 = r^2 - J - 2*V
+	x3 := subMod(subMod(mulMod(r, r), j), addMod(v, v))
+
+	// Y3 = r*(V - X3) - 2*S1*J
+	s1j := mulMod(s1, j)
+	y3 := subMod(mulMod(r, subMod(v, x3)), addMod(s1j, s1j))
+
+	// Z3 = ((Z1+Z2)^2 - Z1Z1 - Z2Z2)*H
+	zz := addMod(p.z, q.z)
+	z3 := mulMod(subMod(subMod(mulMod(zz, zz), z1z1), z2z2), h)
+
+	return jacobianPoint{x: x3, y: y3, z: z3}
+}
+
+func (p jacobianPoint) affine() Point {
+	if p.z.Sign() == 0 {
+		return identity()
+	}
+
+	zInv := new(big.Int).ModInverse(p.z, prime)
+	zInv2 := mulMod(zInv, zInv)
+
+	return Point{x: mulMod(p.x, zInv2), y: mulMod(p.y, mulMod(zInv2, zInv))}
+}
+
+func identity() Point {
+	return Point{x: new(big.Int), y: new(big.Int)}
+}
+
+func pointFromUncompressed(b []byte) Point {
+	return Point{x: new(big.Int).SetBytes(b[1 : 1+ScalarSize]), y: new(big.Int).SetBytes(b[1+ScalarSize:])}
+}
+
+func mulMod(a, b *big.Int) *big.Int {
+	z := new(big.Int).Mul(a, b)
+	return z.Mod(z, prime)
+}
+
+func addMod(a, b *big.Int) *big.Int {
+	z := new(big.Int).Add(a, b)
+	return z.Mod(z, prime)
+}
+
+func subMod(a, b *big.Int) *big.Int {
+	z := new(big.Int).Sub(a, b)
+	return z.Mod(z, prime)
+}
+
+//nolint:gochecknoglobals // these are constants
+var (
+	// prime is the order of the underlying field.
+	prime, _ = new(big.Int).SetString("ffffffff00000001000000000000000000000000ffffffffffffffffffffffff", 16)
+
+	// curveB is the constant b in the curve equation y^2 = x^3 - 3x + b.
+	curveB, _ = new(big.Int).SetString("5ac635d8aa3a93e7b3ebbd55769886bc651d06b0cc53b0f63bce3c3e27d2604b", 16)
+)
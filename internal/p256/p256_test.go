@@ -0,0 +1,171 @@
+package p256_test
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/sha3"
+	"math/big"
+	"testing"
+
+	"github.com/codahale/lockstitch-go/internal/p256"
+)
+
+func TestPoint(t *testing.T) {
+	t.Parallel()
+
+	a, b := p256.ReduceScalar([]byte{0x04, 0xd2}), p256.ReduceScalar([]byte{0x16, 0x2e})
+	zero, one := p256.ReduceScalar(nil), p256.ReduceScalar([]byte{0x01})
+
+	// [a]G + [b]G = [a+b]G
+	got, want := p256.ScalarBaseMult(a).Add(p256.ScalarBaseMult(b)), p256.ScalarBaseMult(one.MulAdd(a, b))
+	if !got.Equal(want) {
+		t.Error("[a]G + [b]G != [a+b]G")
+	}
+
+	// [a]G + [a]G = [2a]G
+	got, want = p256.ScalarBaseMult(a).Add(p256.ScalarBaseMult(a)), p256.ScalarBaseMult(one.MulAdd(a, a))
+	if !got.Equal(want) {
+		t.Error("[a]G + [a]G != [2a]G")
+	}
+
+	// [a]([b]G) = [ab]G
+	if got, want := p256.ScalarBaseMult(b).ScalarMult(a), p256.ScalarBaseMult(a.MulAdd(b, zero)); !got.Equal(want) {
+		t.Error("[a]([b]G) != [ab]G")
+	}
+
+	// P + -P = O
+	if got := p256.ScalarBaseMult(a).Add(p256.ScalarBaseMult(a).Neg()); !got.IsIdentity() {
+		t.Error("P + -P != O")
+	}
+
+	// [0]G = O
+	if got := p256.ScalarBaseMult(zero); !got.IsIdentity() {
+		t.Error("[0]G != O")
+	}
+
+	// Compressed encodings round-trip.
+	enc := p256.ScalarBaseMult(a).Compressed(nil)
+	if got, want := len(enc), p256.CompressedPointSize; got != want {
+		t.Errorf("len(Compressed) = %d, want = %d", got, want)
+	}
+
+	q, ok := p256.ParseCompressedPoint(enc)
+	if !ok || !q.Equal(p256.ScalarBaseMult(a)) {
+		t.Error("ParseCompressedPoint(Compressed(P)) != P")
+	}
+
+	if _, ok := p256.ParseCompressedPoint(enc[1:]); ok {
+		t.Error("ParseCompressedPoint(short) = true, want = false")
+	}
+
+	if _, ok := p256.ParseCompressedPoint([]byte{0x00}); ok {
+		t.Error("ParseCompressedPoint(identity) = true, want = false")
+	}
+
+	// x = 0 is not on the curve.
+	if _, ok := p256.ParseCompressedPoint(make([]byte, p256.CompressedPointSize)); ok {
+		t.Error("ParseCompressedPoint(0x00...) = true, want = false")
+	}
+}
+
+func TestPointFromPublicKey(t *testing.T) {
+	t.Parallel()
+
+	k, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := p256.ScalarFromPrivateKey(k)
+	if !p256.PointFromPublicKey(k.PublicKey()).Equal(p256.ScalarBaseMult(d)) {
+		t.Error("PointFromPublicKey(pub) != [d]G")
+	}
+
+	// ScalarMult agrees with ScalarBaseMult.
+	g := p256.ScalarBaseMult(p256.ReduceScalar([]byte{0x01}))
+	if !g.ScalarMult(d).Equal(p256.ScalarBaseMult(d)) {
+		t.Error("[d]G != ScalarBaseMult(d)")
+	}
+}
+
+func TestDecodeScalar(t *testing.T) {
+	t.Parallel()
+
+	n := order.FillBytes(make([]byte, p256.ScalarSize))
+	if _, ok := p256.DecodeScalar(n); ok {
+		t.Error("DecodeScalar(n) = true, want = false")
+	}
+
+	nMinusOne := bytes.Clone(n)
+	nMinusOne[len(nMinusOne)-1]--
+	k, ok := p256.DecodeScalar(nMinusOne)
+	if !ok {
+		t.Fatal("DecodeScalar(n-1) = false, want = true")
+	}
+
+	if got, want := k.Append(nil), nMinusOne; !bytes.Equal(got, want) {
+		t.Errorf("Append = %x, want = %x", got, want)
+	}
+
+	if _, ok := p256.DecodeScalar(nMinusOne[1:]); ok {
+		t.Error("DecodeScalar(short) = true, want = false")
+	}
+
+	if got, want := p256.ReduceScalar(n).IsZero(), true; got != want {
+		t.Errorf("ReduceScalar(n).IsZero() = %v, want = %v", got, want)
+	}
+}
+
+func TestReduceScalar(t *testing.T) {
+	t.Parallel()
+
+	drbg := sha3.NewSHAKE128()
+	_, _ = drbg.Write([]byte("p256 scalar reduction"))
+
+	for size := range p256.MaxReduceLen + 1 {
+		b := make([]byte, size)
+		_, _ = drbg.Read(b)
+
+		if got, want := p256.ReduceScalar(b).Append(nil), reduce(b); !bytes.Equal(got, want) {
+			t.Errorf("ReduceScalar(%x) = %x, want = %x", b, got, want)
+		}
+	}
+
+	// The largest input is reduced correctly.
+	b := bytes.Repeat([]byte{0xff}, p256.MaxReduceLen)
+	if got, want := p256.ReduceScalar(b).Append(nil), reduce(b); !bytes.Equal(got, want) {
+		t.Errorf("ReduceScalar(%x) = %x, want = %x", b, got, want)
+	}
+}
+
+func TestScalar_MulAdd(t *testing.T) {
+	t.Parallel()
+
+	drbg := sha3.NewSHAKE128()
+	_, _ = drbg.Write([]byte("p256 scalar multiplication"))
+
+	for range 100 {
+		var buf [3 * p256.ScalarSize]byte
+		_, _ = drbg.Read(buf[:])
+
+		k := p256.ReduceScalar(buf[:p256.ScalarSize])
+		b := p256.ReduceScalar(buf[p256.ScalarSize : 2*p256.ScalarSize])
+		c := p256.ReduceScalar(buf[2*p256.ScalarSize:])
+
+		z := new(big.Int).Mul(new(big.Int).SetBytes(k.Append(nil)), new(big.Int).SetBytes(b.Append(nil)))
+		z.Add(z, new(big.Int).SetBytes(c.Append(nil)))
+		z.Mod(z, order)
+
+		if got, want := k.MulAdd(b, c).Append(nil), z.FillBytes(make([]byte, p256.ScalarSize)); !bytes.Equal(got, want) {
+			t.Errorf("MulAdd = %x, want = %x", got, want)
+		}
+	}
+}
+
+func reduce(b []byte) []byte {
+	return new(big.Int).Mod(new(big.Int).SetBytes(b), order).FillBytes(make([]byte, p256.ScalarSize))
+}
+
+//nolint:gochecknoglobals // this is a constant
+var order, _ = new(big.Int).SetString("ffffffff00000000ffffffffffffffffbce6faada7179e84f3b9cac2fc632551", 16)
@@ -0,0 +1,182 @@
+package p256
+
+import (
+	"crypto/ecdh"
+	"encoding/binary"
+	"math/bits"
+)
+
+// MaxReduceLen is the maximum length, in bytes, of the input to ReduceScalar.
+const MaxReduceLen = 2 * ScalarSize
+
+// A Scalar is an integer modulo the order of the group, n. All operations on scalars run in constant time.
+//
+// Scalars are stored as four little-endian 64-bit limbs, fully reduced modulo n. Multiplication uses Montgomery
+// reduction with R = 2^256.
+type Scalar struct {
+	l [4]uint64
+}
+
+// ReduceScalar returns the big-endian integer b reduced modulo the order of the group. For the result to be uniformly
+// distributed, b should be at least 48 bytes long. ReduceScalar panics if b is longer than MaxReduceLen.
+func ReduceScalar(b []byte) Scalar {
+	if len(b) > MaxReduceLen {
+		panic("p256: scalar input too long")
+	}
+
+	var buf [MaxReduceLen]byte
+	copy(buf[MaxReduceLen-len(b):], b)
+
+	// Split the input into two 256-bit halves. Each half is less than 2n, so a single conditional subtraction reduces
+	// it. Multiplying hi by R^2 in the Montgomery domain yields hi*R = hi*2^256 mod n, to which lo is added.
+	hi, lo := load(buf[:ScalarSize]), load(buf[ScalarSize:])
+	hi = reduce(hi, 0)
+	lo = reduce(lo, 0)
+
+	return Scalar{l: add(montMul(&hi, &rr), lo)}
+}
+
+// DecodeScalar decodes a canonical, big-endian encoded scalar. It returns false if b is not ScalarSize bytes long or
+// encodes a value greater than or equal to the order of the group.
+func DecodeScalar(b []byte) (Scalar, bool) {
+	if len(b) != ScalarSize {
+		return Scalar{l: [4]uint64{}}, false
+	}
+
+	x := load(b)
+	_, borrow := sub(x, n)
+	if borrow == 0 {
+		return Scalar{l: [4]uint64{}}, false
+	}
+
+	return Scalar{l: x}, true
+}
+
+// ScalarFromPrivateKey returns the scalar corresponding to the given P-256 private key.
+func ScalarFromPrivateKey(priv *ecdh.PrivateKey) Scalar {
+	// crypto/ecdh has already validated the scalar, so it cannot fail to decode.
+	k, ok := DecodeScalar(priv.Bytes())
+	if !ok {
+		panic("p256: invalid private key")
+	}
+
+	return k
+}
+
+// MulAdd returns k*b + c modulo the order of the group.
+func (k Scalar) MulAdd(b, c Scalar) Scalar {
+	// montMul(k, b) is k*b*R^-1, so a second multiplication by R^2 yields k*b.
+	kb := montMul(&k.l, &b.l)
+	kb = montMul(&kb, &rr)
+
+	return Scalar{l: add(kb, c.l)}
+}
+
+// Append appends the canonical, big-endian encoding of k to b and returns the resulting slice.
+func (k Scalar) Append(b []byte) []byte {
+	b = binary.BigEndian.AppendUint64(b, k.l[3])
+	b = binary.BigEndian.AppendUint64(b, k.l[2])
+	b = binary.BigEndian.AppendUint64(b, k.l[1])
+	return binary.BigEndian.AppendUint64(b, k.l[0])
+}
+
+// IsZero returns true if k is zero.
+func (k Scalar) IsZero() bool {
+	return k.l[0]|k.l[1]|k.l[2]|k.l[3] == 0
+}
+
+// load returns the big-endian 32-byte value b as limbs.
+func load(b []byte) [4]uint64 {
+	return [4]uint64{
+		binary.BigEndian.Uint64(b[24:]),
+		binary.BigEndian.Uint64(b[16:]),
+		binary.BigEndian.Uint64(b[8:]),
+		binary.BigEndian.Uint64(b[0:]),
+	}
+}
+
+// sub returns x-y and the final borrow.
+func sub(x, y [4]uint64) ([4]uint64, uint64) {
+	var z [4]uint64
+	var borrow uint64
+	z[0], borrow = bits.Sub64(x[0], y[0], 0)
+	z[1], borrow = bits.Sub64(x[1], y[1], borrow)
+	z[2], borrow = bits.Sub64(x[2], y[2], borrow)
+	z[3], borrow = bits.Sub64(x[3], y[3], borrow)
+	return z, borrow
+}
+
+// reduce returns carry*2^256 + x reduced modulo n, given that it is less than 2n.
+func reduce(x [4]uint64, carry uint64) [4]uint64 {
+	z, borrow := sub(x, n)
+
+	// Keep x - n unless the subtraction underflowed without a carry to absorb it.
+	mask := -(carry | (borrow ^ 1))
+	for i := range z {
+		z[i] = (z[i] & mask) | (x[i] &^ mask)
+	}
+
+	return z
+}
+
+// add returns x+y modulo n, given that both are reduced.
+func add(x, y [4]uint64) [4]uint64 {
+	var z [4]uint64
+	var carry uint64
+	z[0], carry = bits.Add64(x[0], y[0], 0)
+	z[1], carry = bits.Add64(x[1], y[1], carry)
+	z[2], carry = bits.Add64(x[2], y[2], carry)
+	z[3], carry = bits.Add64(x[3], y[3], carry)
+	return reduce(z, carry)
+}
+
+// montMul returns x*y*R^-1 modulo n, given that both are reduced, using coarsely integrated operand scanning.
+func montMul(x, y *[4]uint64) [4]uint64 {
+	var t [6]uint64
+	for i := range 4 {
+		// t += x * y[i]
+		var c uint64
+		for j := range 4 {
+			hi, lo := bits.Mul64(x[j], y[i])
+			var cc uint64
+			lo, cc = bits.Add64(lo, t[j], 0)
+			hi += cc
+			lo, cc = bits.Add64(lo, c, 0)
+			hi += cc
+			t[j], c = lo, hi
+		}
+		var cc uint64
+		t[4], cc = bits.Add64(t[4], c, 0)
+		t[5] = cc
+
+		// t = (t + m*n) / 2^64, where m is chosen so that the low limb is zero.
+		m := t[0] * n0inv
+		hi, lo := bits.Mul64(m, n[0])
+		_, cc = bits.Add64(lo, t[0], 0)
+		c = hi + cc
+		for j := 1; j < 4; j++ {
+			hi, lo := bits.Mul64(m, n[j])
+			lo, cc = bits.Add64(lo, t[j], 0)
+			hi += cc
+			lo, cc = bits.Add64(lo, c, 0)
+			hi += cc
+			t[j-1], c = lo, hi
+		}
+		t[3], cc = bits.Add64(t[4], c, 0)
+		t[4] = t[5] + cc
+	}
+
+	return reduce([4]uint64{t[0], t[1], t[2], t[3]}, t[4])
+}
+
+//nolint:gochecknoglobals // these are constants
+var (
+	// n is the order of the group.
+	n = [4]uint64{0xf3b9cac2fc632551, 0xbce6faada7179e84, 0xffffffffffffffff, 0xffffffff00000000}
+
+	// rr is R^2 modulo n.
+	rr = [4]uint64{0x83244c95be79eea2, 0x4699799c49bd6fa6, 0x2845b2392b6bec59, 0x66e12d94f3d95620}
+)
+
+// n0inv is -n^-1 modulo 2^64.
+const n0inv = 0xccd1c8aaee00bc4f
@@ -0,0 +1,31 @@
+package p256
+
+import (
+	"github.com/codahale/lockstitch-go"
+)
+
+// CommitmentScalar derives a hedged commitment scalar from a clone of the protocol, the signer's private key, and the
+// random data. The private key is mixed in with the given label. The protocol is not modified.
+//
+// Signing remains secure if the random data is predictable, and a signer's private key is not exposed by signing the
+// same message twice.
+func CommitmentScalar(p *lockstitch.Protocol, keyLabel string, d Scalar, random []byte) Scalar {
+	hedge := p.Clone()
+	hedge.Mix(keyLabel, d.Append(make([]byte, 0, ScalarSize)))
+	hedge.Mix("hedged randomness", random)
+	return ReduceScalar(hedge.Derive("commitment scalar", nil, commitmentScalarLen))
+}
+
+// Challenge derives a challenge scalar from the protocol.
+func Challenge(p *lockstitch.Protocol) Scalar {
+	return ReduceScalar(p.Derive("challenge", nil, challengeLen))
+}
+
+const (
+	// commitmentScalarLen is the number of bytes derived for a commitment scalar. Deriving 64 bytes ensures the scalar
+	// is uniformly distributed after reduction.
+	commitmentScalarLen = 64
+
+	// challengeLen is the number of bytes derived for a challenge scalar, as specified in the design document.
+	challengeLen = 32
+)
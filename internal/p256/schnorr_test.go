@@ -0,0 +1,29 @@
+package p256_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/codahale/lockstitch-go"
+	"github.com/codahale/lockstitch-go/internal/p256"
+)
+
+func TestCommitmentScalar(t *testing.T) {
+	t.Parallel()
+
+	d := p256.ReduceScalar([]byte("a private key"))
+	p := lockstitch.NewProtocol("com.example.schnorr")
+	want := p.Clone().Derive("output", nil, 16)
+
+	k1 := p256.CommitmentScalar(p, "private key", d, []byte("random"))
+	k2 := p256.CommitmentScalar(p, "private key", d, []byte("other random"))
+
+	if bytes.Equal(k1.Append(nil), k2.Append(nil)) {
+		t.Error("CommitmentScalar ignored the random data")
+	}
+
+	// The protocol is not modified.
+	if got := p.Derive("output", nil, 16); !bytes.Equal(got, want) {
+		t.Errorf("Derive = %x, want = %x", got, want)
+	}
+}
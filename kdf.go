@@ -0,0 +1,54 @@
+package lockstitch
+
+// A KDF derives multiple independent subkeys from a single secret, in the style of HKDF's Extract and Expand functions.
+//
+// A KDF is equivalent to initializing a protocol with a domain separation string and mixing the salt, the secret, and
+// the context into it using the labels "salt", "secret", and "context". Each subkey is derived from a separate clone of
+// that protocol using the subkey's label, so subkeys are independent of each other and of the order in which they are
+// derived. A KDF is safe for concurrent use.
+type KDF struct {
+	base *Protocol
+}
+
+// NewKDF returns a KDF which derives subkeys from the given secret, salt, and context using the given domain
+// separation string.
+func NewKDF(domain string, secret, salt, context []byte) *KDF {
+	p := NewProtocol(domain)
+	p.Mix("salt", salt)
+	p.Mix("secret", secret)
+	p.Mix("context", context)
+
+	return &KDF{base: p}
+}
+
+// Derive derives an n-byte subkey with the given label, appends it to dst, and returns the resulting slice. Deriving
+// the same label always produces the same subkey, while subkeys with different labels or lengths are independent.
+//
+// Derive panics if n is negative or greater than 64GiB.
+func (k *KDF) Derive(label string, dst []byte, n int) []byte {
+	return k.base.Clone().Derive(label, dst, n)
+}
+
+// DeriveKeys derives a subkey for each label in the given map of labels to lengths, and returns a map of labels to
+// subkeys. Each subkey is identical to the subkey Derive returns for the same label and length.
+//
+// DeriveKeys panics if any length is negative or greater than 64GiB.
+func (k *KDF) DeriveKeys(lengths map[string]int) map[string][]byte {
+	total := 0
+	for _, n := range lengths {
+		if n < 0 {
+			panic("invalid argument to DeriveKeys: length must be non-negative")
+		}
+		total += n
+	}
+
+	// Allocate all the subkeys from a single buffer.
+	buf := make([]byte, 0, total)
+	keys := make(map[string][]byte, len(lengths))
+	for label, n := range lengths {
+		keys[label] = k.Derive(label, buf[len(buf):len(buf):len(buf)+n], n)
+		buf = buf[:len(buf)+n]
+	}
+
+	return keys
+}
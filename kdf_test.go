@@ -0,0 +1,68 @@
+package lockstitch_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/codahale/lockstitch-go"
+)
+
+func TestKDF(t *testing.T) {
+	t.Parallel()
+
+	kdf := lockstitch.NewKDF("com.example.kdf", []byte("secret"), []byte("salt"), []byte("context"))
+
+	encKey := kdf.Derive("encryption key", nil, 16)
+	if got, want := hex.EncodeToString(encKey), "69bb1efd57cf7256e4cdd5c8f2c6b98c"; got != want {
+		t.Errorf("Derive = %v, want = %v", got, want)
+	}
+
+	// Subkeys are independent of the order in which they are derived.
+	macKey := kdf.Derive("mac key", nil, 32)
+	if got, want := kdf.Derive("encryption key", nil, 16), encKey; !bytes.Equal(got, want) {
+		t.Errorf("Derive(encryption key) = %x, want = %x", got, want)
+	}
+
+	other := lockstitch.NewKDF("com.example.kdf", []byte("secret"), []byte("salt"), []byte("context"))
+	if got, want := other.Derive("mac key", nil, 32), macKey; !bytes.Equal(got, want) {
+		t.Errorf("Derive(mac key) = %x, want = %x", got, want)
+	}
+
+	// Subkeys with different labels, lengths, or inputs are independent.
+	for name, key := range map[string][]byte{
+		"different label":   kdf.Derive("iv", nil, 16),
+		"different length":  kdf.Derive("encryption key", nil, 17)[:16],
+		"different secret":  lockstitch.NewKDF("com.example.kdf", []byte("secret!"), []byte("salt"), []byte("context")).Derive("encryption key", nil, 16),
+		"different salt":    lockstitch.NewKDF("com.example.kdf", []byte("secret"), []byte("salt!"), []byte("context")).Derive("encryption key", nil, 16),
+		"different context": lockstitch.NewKDF("com.example.kdf", []byte("secret"), []byte("salt"), []byte("context!")).Derive("encryption key", nil, 16),
+	} {
+		if bytes.Equal(key, encKey) {
+			t.Errorf("%s: subkey = %x, which is not independent", name, key)
+		}
+	}
+}
+
+func TestKDF_DeriveKeys(t *testing.T) {
+	t.Parallel()
+
+	kdf := lockstitch.NewKDF("com.example.kdf", []byte("secret"), []byte("salt"), []byte("context"))
+	lengths := map[string]int{"encryption key": 16, "mac key": 32, "iv": 12, "empty": 0}
+
+	keys := kdf.DeriveKeys(lengths)
+	if got, want := len(keys), len(lengths); got != want {
+		t.Errorf("len(DeriveKeys) = %d, want = %d", got, want)
+	}
+
+	for label, n := range lengths {
+		if got, want := keys[label], kdf.Derive(label, nil, n); !bytes.Equal(got, want) {
+			t.Errorf("DeriveKeys[%q] = %x, want = %x", label, got, want)
+		}
+	}
+
+	// Modifying one subkey does not affect the others.
+	keys["encryption key"] = append(keys["encryption key"], 1, 2, 3)
+	if got, want := keys["mac key"], kdf.Derive("mac key", nil, 32); !bytes.Equal(got, want) {
+		t.Errorf("DeriveKeys[mac key] = %x, want = %x", got, want)
+	}
+}
@@ -0,0 +1,116 @@
+// Package keywrap implements deterministic key wrapping for data encryption keys using Lockstitch.
+//
+// A data encryption key (DEK) is wrapped under a key encryption key (KEK) by initializing a protocol, mixing in the
+// envelope version, the KEK, the KEK's identifier, and any metadata, and sealing the DEK. Because DEKs are random and
+// unique, the wrapping does not require a nonce: wrapping the same DEK with the same inputs produces the same envelope.
+//
+// An envelope consists of a version byte, the big-endian 16-bit length of the key ID, the key ID, and the sealed DEK and
+// its authentication tag. The key ID is stored in the clear so that the KEK can be located before unwrapping. The
+// metadata is not stored and must be provided again to unwrap the DEK.
+package keywrap
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+
+	"github.com/codahale/lockstitch-go"
+)
+
+// MinKEKSize is the minimum size, in bytes, of a KEK.
+const MinKEKSize = 16
+
+var (
+	// ErrInvalidKEK is returned when a KEK is shorter than MinKEKSize.
+	ErrInvalidKEK = errors.New("keywrap: invalid KEK")
+
+	// ErrInvalidKeyID is returned when a key ID is longer than 65535 bytes.
+	ErrInvalidKeyID = errors.New("keywrap: invalid key ID")
+
+	// ErrInvalidEnvelope is returned when an envelope is malformed or has an unsupported version.
+	ErrInvalidEnvelope = errors.New("keywrap: invalid envelope")
+)
+
+// Wrap wraps the DEK under the KEK with the given key ID, binding it to the given metadata, and returns the envelope.
+func Wrap(kek []byte, keyID string, dek, metadata []byte) ([]byte, error) {
+	if len(kek) < MinKEKSize {
+		return nil, ErrInvalidKEK
+	}
+
+	if len(keyID) > math.MaxUint16 {
+		return nil, ErrInvalidKeyID
+	}
+
+	envelope := make([]byte, 0, headerLen+len(keyID)+len(dek)+lockstitch.TagLen)
+	envelope = append(envelope, version)
+	envelope = binary.BigEndian.AppendUint16(envelope, uint16(len(keyID)))
+	envelope = append(envelope, keyID...)
+
+	return newProtocol(kek, keyID, metadata).Seal("dek", envelope, dek), nil
+}
+
+// Unwrap unwraps the DEK in the envelope using the KEK and the metadata the DEK was wrapped with. If the KEK or
+// metadata are incorrect or the envelope has been modified, it returns lockstitch.ErrInvalidCiphertext.
+func Unwrap(kek, envelope, metadata []byte) ([]byte, error) {
+	if len(kek) < MinKEKSize {
+		return nil, ErrInvalidKEK
+	}
+
+	keyID, sealed, err := parse(envelope)
+	if err != nil {
+		return nil, err
+	}
+
+	return newProtocol(kek, keyID, metadata).Open("dek", nil, sealed)
+}
+
+// Rewrap unwraps the DEK in the envelope using the old KEK and wraps it under the new KEK with the new key ID, binding it
+// to the same metadata. The DEK is never returned to the caller.
+func Rewrap(oldKEK, newKEK []byte, newKeyID string, envelope, metadata []byte) ([]byte, error) {
+	if len(newKEK) < MinKEKSize {
+		return nil, ErrInvalidKEK
+	}
+
+	dek, err := Unwrap(oldKEK, envelope, metadata)
+	if err != nil {
+		return nil, err
+	}
+	defer clear(dek)
+
+	return Wrap(newKEK, newKeyID, dek, metadata)
+}
+
+// KeyID returns the ID of the KEK the envelope's DEK is wrapped under.
+func KeyID(envelope []byte) (string, error) {
+	keyID, _, err := parse(envelope)
+	return keyID, err
+}
+
+// parse returns the key ID and sealed DEK of the envelope.
+func parse(envelope []byte) (keyID string, sealed []byte, err error) {
+	if len(envelope) < headerLen || envelope[0] != version {
+		return "", nil, ErrInvalidEnvelope
+	}
+
+	n := int(binary.BigEndian.Uint16(envelope[1:]))
+	if len(envelope) < headerLen+n+lockstitch.TagLen {
+		return "", nil, ErrInvalidEnvelope
+	}
+
+	return string(envelope[headerLen : headerLen+n]), envelope[headerLen+n:], nil
+}
+
+// newProtocol returns a protocol with the envelope version, KEK, key ID, and metadata mixed in.
+func newProtocol(kek []byte, keyID string, metadata []byte) *lockstitch.Protocol {
+	p := lockstitch.NewProtocol("lockstitch-go.keywrap")
+	p.Mix("version", []byte{version})
+	p.Mix("kek", kek)
+	p.Mix("key id", []byte(keyID))
+	p.Mix("metadata", metadata)
+	return p
+}
+
+const (
+	version   = 0x01  // The current envelope version.
+	headerLen = 1 + 2 // The length, in bytes, of the version and key ID length.
+)
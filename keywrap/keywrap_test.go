@@ -0,0 +1,142 @@
+package keywrap_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/codahale/lockstitch-go"
+	"github.com/codahale/lockstitch-go/keywrap"
+)
+
+func TestWrap(t *testing.T) {
+	t.Parallel()
+
+	kek := []byte("yellow submarine")
+	dek := []byte("this is a data encryption key!!!")
+	metadata := []byte("table=users,column=email")
+
+	envelope, err := keywrap.Wrap(kek, "kek-1", dek, metadata)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := hex.EncodeToString(envelope), "0100056b656b2d315c3d0f17630303db69c422908045c634ac19d525d338d4d6cd50cab7acf3e37c4d8d683c8fae1ee62cfbb22da060ee1a"; got != want {
+		t.Errorf("Wrap = %v, want = %v", got, want)
+	}
+
+	keyID, err := keywrap.KeyID(envelope)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := keyID, "kek-1"; got != want {
+		t.Errorf("KeyID = %q, want = %q", got, want)
+	}
+
+	got, err := keywrap.Unwrap(kek, envelope, metadata)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(got, dek) {
+		t.Errorf("Unwrap = %x, want = %x", got, dek)
+	}
+
+	// Wrapping is deterministic.
+	again, err := keywrap.Wrap(kek, "kek-1", dek, metadata)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(again, envelope) {
+		t.Errorf("Wrap = %x, want = %x", again, envelope)
+	}
+
+	if _, err := keywrap.Unwrap([]byte("another kek here"), envelope, metadata); !errors.Is(err, lockstitch.ErrInvalidCiphertext) {
+		t.Errorf("Unwrap(wrong KEK) = %v, want = %v", err, lockstitch.ErrInvalidCiphertext)
+	}
+
+	if _, err := keywrap.Unwrap(kek, envelope, []byte("table=users,column=name")); !errors.Is(err, lockstitch.ErrInvalidCiphertext) {
+		t.Errorf("Unwrap(wrong metadata) = %v, want = %v", err, lockstitch.ErrInvalidCiphertext)
+	}
+
+	for i := 3; i < len(envelope); i++ {
+		modified := bytes.Clone(envelope)
+		modified[i] ^= 1
+		if _, err := keywrap.Unwrap(kek, modified, metadata); !errors.Is(err, lockstitch.ErrInvalidCiphertext) {
+			t.Errorf("Unwrap(envelope with byte %d modified) = %v, want = %v", i, err, lockstitch.ErrInvalidCiphertext)
+		}
+	}
+}
+
+func TestRewrap(t *testing.T) {
+	t.Parallel()
+
+	oldKEK, newKEK := []byte("yellow submarine"), []byte("another kek here")
+	dek := []byte("this is a data encryption key!!!")
+	metadata := []byte("table=users,column=email")
+
+	envelope, err := keywrap.Wrap(oldKEK, "kek-1", dek, metadata)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rewrapped, err := keywrap.Rewrap(oldKEK, newKEK, "kek-2", envelope, metadata)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if keyID, err := keywrap.KeyID(rewrapped); err != nil || keyID != "kek-2" {
+		t.Errorf("KeyID = %q, %v, want = %q, nil", keyID, err, "kek-2")
+	}
+
+	got, err := keywrap.Unwrap(newKEK, rewrapped, metadata)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(got, dek) {
+		t.Errorf("Unwrap(Rewrap) = %x, want = %x", got, dek)
+	}
+
+	if _, err := keywrap.Unwrap(oldKEK, rewrapped, metadata); !errors.Is(err, lockstitch.ErrInvalidCiphertext) {
+		t.Errorf("Unwrap(old KEK) = %v, want = %v", err, lockstitch.ErrInvalidCiphertext)
+	}
+
+	if _, err := keywrap.Rewrap(newKEK, newKEK, "kek-3", envelope, metadata); !errors.Is(err, lockstitch.ErrInvalidCiphertext) {
+		t.Errorf("Rewrap(wrong old KEK) = %v, want = %v", err, lockstitch.ErrInvalidCiphertext)
+	}
+}
+
+func TestInvalidInputs(t *testing.T) {
+	t.Parallel()
+
+	kek := []byte("yellow submarine")
+
+	if _, err := keywrap.Wrap(kek[:15], "kek-1", nil, nil); !errors.Is(err, keywrap.ErrInvalidKEK) {
+		t.Errorf("Wrap(short KEK) = %v, want = %v", err, keywrap.ErrInvalidKEK)
+	}
+
+	if _, err := keywrap.Wrap(kek, strings.Repeat("a", 1<<16), nil, nil); !errors.Is(err, keywrap.ErrInvalidKeyID) {
+		t.Errorf("Wrap(long key ID) = %v, want = %v", err, keywrap.ErrInvalidKeyID)
+	}
+
+	envelope, err := keywrap.Wrap(kek, "kek-1", []byte("dek"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, modified := range map[string][]byte{
+		"empty":           nil,
+		"unknown version": append([]byte{0x02}, envelope[1:]...),
+		"truncated":       envelope[:len(envelope)-len("dek")-lockstitch.TagLen-1],
+		"bad key ID len":  append([]byte{0x01, 0xff, 0xff}, envelope[3:]...),
+	} {
+		if _, err := keywrap.Unwrap(kek, modified, nil); !errors.Is(err, keywrap.ErrInvalidEnvelope) {
+			t.Errorf("Unwrap(%s) = %v, want = %v", name, err, keywrap.ErrInvalidEnvelope)
+		}
+	}
+}
@@ -10,11 +10,13 @@
 package lockstitch
 
 import (
+	"crypto/cipher"
 	"crypto/sha256"
 	"crypto/subtle"
 	"encoding"
 	"errors"
 	"hash"
+	"io"
 	"slices"
 
 	"github.com/codahale/lockstitch-go/internal/aes"
@@ -28,12 +30,18 @@ const TagLen = 16
 // wrong key.
 var ErrInvalidCiphertext = errors.New("lockstitch: invalid ciphertext")
 
+var (
+	errMixWriterClosed    = errors.New("lockstitch: write to closed MixWriter")
+	errDeriveReaderClosed = errors.New("lockstitch: read from closed DeriveReader")
+)
+
 // A Protocol is a stateful object providing fine-grained symmetric-key cryptographic services like hashing, message
 // authentication codes, pseudo-random functions, authenticated encryption, and more.
 type Protocol struct {
 	_          noCopy
 	transcript hash.Hash
 	buf        []byte
+	rec        *Recorder
 }
 
 // NewProtocol creates a new Protocol with the given domain separation string.
@@ -61,6 +69,23 @@ func (p *Protocol) Mix(label string, input []byte) {
 	metadata = tuplehash.AppendLeftEncode(metadata, uint64(len(input))*bitsPerByte)
 	p.transcript.Write(metadata)
 	p.transcript.Write(input)
+	p.record(OpMix, label, uint64(len(input)), input)
+}
+
+// MixWriter returns a MixWriter which mixes all data written to it into the protocol's state using the given label.
+// Unlike Mix, MixWriter does not require the length of the input to be known in advance, which allows for large inputs
+// (e.g., files or network streams) to be mixed without buffering them in memory.
+//
+// The protocol must not be used until the returned MixWriter has been closed.
+func (p *Protocol) MixWriter(label string) *MixWriter {
+	// Append the operation metadata to the transcript.
+	metadata := p.reuseBuf(1 + tuplehash.MaxLen + len(label))
+	metadata[0] = opMixStream
+	metadata = tuplehash.AppendLeftEncode(metadata, uint64(len(label))*bitsPerByte)
+	metadata = append(metadata, label...)
+	p.transcript.Write(metadata)
+
+	return &MixWriter{p: p, label: label, buf: make([]byte, 0, mixChunkLen), n: 0, closed: false}
 }
 
 // Derive generates pseudorandom output from the Protocol's current state, the label, and the output length, then
@@ -71,7 +96,7 @@ func (p *Protocol) Mix(label string, input []byte) {
 func (p *Protocol) Derive(label string, dst []byte, n int) []byte {
 	if n < 0 {
 		panic("invalid argument to Derive: n cannot be negative")
-	} else if uint64(n) > 64*1024*1024*1024 {
+	} else if uint64(n) > maxDeriveLen {
 		panic("invalid argument to Derive: n must be <= 64GiB")
 	}
 
@@ -82,6 +107,7 @@ func (p *Protocol) Derive(label string, dst []byte, n int) []byte {
 	metadata = append(metadata, label...)
 	metadata = tuplehash.AppendLeftEncode(metadata, uint64(n)*bitsPerByte)
 	p.transcript.Write(metadata)
+	p.record(OpDerive, label, uint64(n), nil)
 
 	// Expand a PRF key.
 	var keys [expandBufLen]byte
@@ -98,6 +124,29 @@ func (p *Protocol) Derive(label string, dst []byte, n int) []byte {
 	return ret
 }
 
+// DeriveReader returns a DeriveReader which generates up to 64GiB of pseudorandom output from the protocol's current
+// state and the label. Once 64GiB of output has been read, the DeriveReader returns io.EOF.
+//
+// Unlike Derive, the output of a DeriveReader does not depend on the amount of output read: reading n bytes returns a
+// prefix of the output from reading more than n bytes. Callers which require outputs of different lengths to be
+// unrelated should use Derive instead.
+//
+// The protocol must not be used until the returned DeriveReader has been closed.
+func (p *Protocol) DeriveReader(label string) *DeriveReader {
+	// Append the operation metadata to the transcript.
+	metadata := p.reuseBuf(1 + tuplehash.MaxLen + len(label))
+	metadata[0] = opDeriveStream
+	metadata = tuplehash.AppendLeftEncode(metadata, uint64(len(label))*bitsPerByte)
+	metadata = append(metadata, label...)
+	p.transcript.Write(metadata)
+
+	// Expand a PRF key and use it to create an AES-128-CTR keystream.
+	var keys [expandBufLen]byte
+	prfKey := p.expand("prf key", keys[:0])
+
+	return &DeriveReader{p: p, label: label, prf: aes.NewCTR(prfKey, zeroIV[:]), n: 0, closed: false}
+}
+
 // Encrypt encrypts the plaintext using the protocol's current state as the key, then ratchets the protocol's state
 // using the label and input. It appends the ciphertext to dst and returns the resulting slice.
 //
@@ -125,6 +174,7 @@ func (p *Protocol) Encrypt(label string, dst, plaintext []byte) []byte {
 
 	// Append the authenticator to the transcript.
 	p.transcript.Write(auth)
+	p.record(OpCrypt, label, uint64(len(plaintext)), nil)
 
 	// Encrypt the plaintext using AES-128-CTR.
 	aes.CTR(dek, zeroIV[:], ciphertext, plaintext)
@@ -164,6 +214,7 @@ func (p *Protocol) Decrypt(label string, dst, ciphertext []byte) []byte {
 
 	// Append the authenticator to the transcript.
 	p.transcript.Write(auth)
+	p.record(OpCrypt, label, uint64(len(plaintext)), nil)
 
 	// Ratchet the transcript.
 	p.ratchet(dek[:0])
@@ -171,6 +222,26 @@ func (p *Protocol) Decrypt(label string, dst, ciphertext []byte) []byte {
 	return ret
 }
 
+// EncryptStream returns a CryptStream which incrementally encrypts a plaintext of unknown length using the protocol's
+// current state as the key. When the CryptStream is closed, the protocol's state is ratcheted using the label and the
+// plaintext.
+//
+// Like Encrypt, EncryptStream provides no authentication. The protocol must not be used until the returned CryptStream
+// has been closed.
+func (p *Protocol) EncryptStream(label string) *CryptStream {
+	return p.cryptStream(label, false)
+}
+
+// DecryptStream returns a CryptStream which incrementally decrypts a ciphertext of unknown length using the protocol's
+// current state as the key. When the CryptStream is closed, the protocol's state is ratcheted using the label and the
+// plaintext.
+//
+// Like Decrypt, DecryptStream provides no authentication. The protocol must not be used until the returned CryptStream
+// has been closed.
+func (p *Protocol) DecryptStream(label string) *CryptStream {
+	return p.cryptStream(label, true)
+}
+
 // Seal encrypts the given plaintext using the protocol's current state as the key, appending an authentication tag of
 // TagLen bytes, then ratchets the protocol's state using the label and input. It appends the ciphertext and
 // authentication tag to dst and returns the resulting slice.
@@ -200,6 +271,7 @@ func (p *Protocol) Seal(label string, dst, plaintext []byte) []byte {
 
 	// Append the authenticator to the transcript.
 	p.transcript.Write(auth)
+	p.record(OpAuthCrypt, label, uint64(len(plaintext)), nil)
 
 	// Expand an authentication tag.
 	copy(tag, p.expand("authentication tag", dak[:0]))
@@ -215,7 +287,8 @@ func (p *Protocol) Seal(label string, dst, plaintext []byte) []byte {
 
 // Open decrypts the given slice in place using the protocol's current state as the key, verifying the final TagLen
 // bytes as an authentication tag. If the ciphertext is authentic, it appends the plaintext to dst and returns the
-// resulting slice; otherwise, ErrInvalidCiphertext is returned.
+// resulting slice; otherwise, ErrInvalidCiphertext is returned and the unauthenticated plaintext written to dst's
+// remaining capacity is cleared.
 //
 // To reuse ciphertext's storage for the decrypted output, use ciphertext[:0] as dst. Otherwise, the remaining capacity
 // of dst must not overlap ciphertext.
@@ -245,14 +318,17 @@ func (p *Protocol) Open(label string, dst, ciphertext []byte) ([]byte, error) {
 
 	// Append the authenticator to the transcript.
 	p.transcript.Write(auth)
+	p.record(OpAuthCrypt, label, uint64(len(plaintext)), nil)
 
 	// Expand a counterfactual authentication tag.
 	tagP := p.expand("authentication tag", dak[:0])
 	// Ratchet the transcript.
 	p.ratchet(dek[:0])
 
-	// Compare the tag and the counterfactual tag in constant time.
+	// Compare the tag and the counterfactual tag in constant time. If they don't match, clear the unauthenticated
+	// plaintext.
 	if subtle.ConstantTimeCompare(tag, tagP) == 0 {
+		clear(plaintext)
 		return nil, ErrInvalidCiphertext
 	}
 	return ret, nil
@@ -292,6 +368,207 @@ func (p *Protocol) MarshalBinary() (data []byte, err error) {
 	return p.transcript.(encoding.BinaryMarshaler).MarshalBinary() //nolint:errcheck // cannot panic
 }
 
+// A MixWriter mixes an input of unknown length into a protocol's state. It splits the input into chunks of a fixed
+// size, each of which is encoded with its length, and terminates the input with an empty chunk.
+type MixWriter struct {
+	p      *Protocol
+	label  string
+	buf    []byte
+	n      uint64
+	closed bool
+}
+
+// Write mixes the given data into the protocol's state. It returns an error only if the MixWriter has been closed.
+func (w *MixWriter) Write(b []byte) (int, error) {
+	if w.closed {
+		return 0, errMixWriterClosed
+	}
+
+	n := len(b)
+	w.n += uint64(n)
+	for len(b) > 0 {
+		// If there's nothing buffered, write full chunks directly from the input.
+		if len(w.buf) == 0 && len(b) >= mixChunkLen {
+			w.writeChunk(b[:mixChunkLen])
+			b = b[mixChunkLen:]
+			continue
+		}
+
+		// Otherwise, buffer as much of the input as possible, writing the buffer as a chunk if it's full.
+		c := copy(w.buf[len(w.buf):cap(w.buf)], b)
+		w.buf = w.buf[:len(w.buf)+c]
+		b = b[c:]
+		if len(w.buf) == mixChunkLen {
+			w.writeChunk(w.buf)
+			w.buf = w.buf[:0]
+		}
+	}
+
+	return n, nil
+}
+
+// Close writes any buffered data and the terminating empty chunk to the protocol's transcript. After Close returns, the
+// protocol may be used again. Calling Close more than once has no effect.
+func (w *MixWriter) Close() error {
+	if w.closed {
+		return nil
+	}
+
+	// Write any remaining buffered data as a final, short chunk.
+	if len(w.buf) > 0 {
+		w.writeChunk(w.buf)
+		w.buf = w.buf[:0]
+	}
+
+	// Terminate the input with an empty chunk.
+	w.writeChunk(nil)
+	w.p.record(OpMixStream, w.label, w.n, nil)
+	w.closed = true
+
+	return nil
+}
+
+// writeChunk appends the length of the chunk and the chunk itself to the protocol's transcript.
+func (w *MixWriter) writeChunk(chunk []byte) {
+	var lenBuf [tuplehash.MaxLen]byte
+	w.p.transcript.Write(tuplehash.AppendLeftEncode(lenBuf[:0], uint64(len(chunk))*bitsPerByte))
+	w.p.transcript.Write(chunk)
+}
+
+// A DeriveReader generates pseudorandom output from a protocol's state. Its output is limited to 64GiB.
+type DeriveReader struct {
+	p      *Protocol
+	label  string
+	prf    cipher.Stream
+	n      uint64
+	closed bool
+}
+
+// Read fills b with pseudorandom output. It returns io.EOF once 64GiB of output has been read and an error if the
+// DeriveReader has been closed.
+func (r *DeriveReader) Read(b []byte) (int, error) {
+	if r.closed {
+		return 0, errDeriveReaderClosed
+	}
+
+	// Limit the output to 64GiB to avoid birthday bound distinguishing attacks.
+	remaining := maxDeriveLen - r.n
+	if remaining == 0 {
+		return 0, io.EOF
+	}
+	if uint64(len(b)) > remaining {
+		b = b[:remaining]
+	}
+
+	// Generate AES-128-CTR keystream for PRF output.
+	clear(b)
+	r.prf.XORKeyStream(b, b)
+	r.n += uint64(len(b))
+
+	return len(b), nil
+}
+
+// Close finalizes the DeriveReader by appending the total length of the output read to the protocol's transcript and
+// ratcheting the protocol's state. After Close returns, the protocol may be used again. Calling Close more than once has
+// no effect.
+func (r *DeriveReader) Close() error {
+	if r.closed {
+		return nil
+	}
+
+	// Append the output length to the transcript.
+	var lenBuf [tuplehash.MaxLen]byte
+	r.p.transcript.Write(tuplehash.AppendLeftEncode(lenBuf[:0], r.n*bitsPerByte))
+	r.p.record(OpDeriveStream, r.label, r.n, nil)
+
+	// Ratchet the transcript.
+	var rak [expandBufLen]byte
+	r.p.ratchet(rak[:0])
+	r.prf = nil
+	r.closed = true
+
+	return nil
+}
+
+// A CryptStream encrypts or decrypts a stream of data using a protocol's state as the key. It implements cipher.Stream,
+// and can be used with cipher.StreamReader and cipher.StreamWriter.
+type CryptStream struct {
+	p       *Protocol
+	label   string
+	ctr     cipher.Stream
+	gmac    *aes.GMACWriter
+	decrypt bool
+	n       uint64
+	closed  bool
+}
+
+// XORKeyStream encrypts or decrypts each byte in src, writing the output to dst. Dst and src must overlap entirely or
+// not at all. XORKeyStream panics if the CryptStream has been closed or if len(dst) < len(src).
+func (s *CryptStream) XORKeyStream(dst, src []byte) {
+	if s.closed {
+		panic("lockstitch: XORKeyStream called on closed CryptStream")
+	}
+
+	if s.decrypt {
+		// Decrypt the ciphertext, then calculate the authenticator of the plaintext.
+		s.ctr.XORKeyStream(dst, src)
+		_, _ = s.gmac.Write(dst[:len(src)])
+	} else {
+		// Calculate the authenticator of the plaintext, then encrypt it.
+		_, _ = s.gmac.Write(src)
+		s.ctr.XORKeyStream(dst, src)
+	}
+	s.n += uint64(len(src))
+}
+
+// Close finalizes the CryptStream by appending the length of the plaintext and an AES-128-GMAC authenticator of the
+// plaintext to the protocol's transcript and ratcheting the protocol's state. After Close returns, the protocol may be
+// used again. Calling Close more than once has no effect.
+func (s *CryptStream) Close() error {
+	if s.closed {
+		return nil
+	}
+
+	// Append the plaintext length and the authenticator to the transcript.
+	var buf [expandBufLen]byte
+	s.p.transcript.Write(tuplehash.AppendLeftEncode(buf[:0], s.n*bitsPerByte))
+	s.p.transcript.Write(s.gmac.Sum(buf[:0]))
+	s.p.record(OpCryptStream, s.label, s.n, nil)
+
+	// Ratchet the transcript.
+	s.p.ratchet(buf[:0])
+	s.ctr, s.gmac = nil, nil
+	s.closed = true
+
+	return nil
+}
+
+// cryptStream appends the metadata of a streaming encryption operation to the transcript and returns a CryptStream
+// using keys derived from it.
+func (p *Protocol) cryptStream(label string, decrypt bool) *CryptStream {
+	// Append the operation metadata to the transcript.
+	metadata := p.reuseBuf(1 + tuplehash.MaxLen + len(label))
+	metadata[0] = opCryptStream
+	metadata = tuplehash.AppendLeftEncode(metadata, uint64(len(label))*bitsPerByte)
+	metadata = append(metadata, label...)
+	p.transcript.Write(metadata)
+
+	// Expand a data encryption key and a data authentication key from the transcript.
+	var keys [expandBufLen * 2]byte
+	dek := p.expand("data encryption key", keys[:0])
+	dak := p.expand("data authentication key", keys[expandBufLen:expandBufLen])
+
+	return &CryptStream{
+		p:       p,
+		label:   label,
+		ctr:     aes.NewCTR(dek, zeroIV[:]),
+		gmac:    aes.NewGMACWriter(dak, zeroNonce[:]),
+		decrypt: decrypt,
+		n:       0,
+		closed:  false,
+	}
+}
+
 // ratchet replaces the protocol's transcript with a ratchet operation code and a ratchet key derived from the previous
 // protocol transcript.
 func (p *Protocol) ratchet(dst []byte) {
@@ -307,6 +584,7 @@ func (p *Protocol) ratchet(dst []byte) {
 	metadata = tuplehash.AppendLeftEncode(metadata, uint64(len(rak))*bitsPerByte)
 	p.transcript.Write(metadata)
 	p.transcript.Write(rak)
+	p.record(OpRatchet, "", uint64(len(rak)), nil)
 }
 
 // expand clones the protocol's transcript, appends an expand operation code, the label length, the label, and the
@@ -348,6 +626,10 @@ var (
 	_ encoding.BinaryMarshaler   = (*Protocol)(nil)
 	_ encoding.BinaryUnmarshaler = (*Protocol)(nil)
 	_ encoding.BinaryAppender    = (*Protocol)(nil)
+	_ io.WriteCloser             = (*MixWriter)(nil)
+	_ io.ReadCloser              = (*DeriveReader)(nil)
+	_ cipher.Stream              = (*CryptStream)(nil)
+	_ io.Closer                  = (*CryptStream)(nil)
 )
 
 // sliceForAppend takes a slice and a requested number of bytes. It returns a slice with the contents of the given slice
@@ -367,21 +649,26 @@ var (
 )
 
 const (
-	opInit      = 0x01 // Initializes a protocol with a domain separation string.
-	opMix       = 0x02 // Mixes a labeled input value into the protocol's state.
-	opDerive    = 0x03 // Derives pseudorandom data from the protocol's transcript.
-	opCrypt     = 0x04 // Encrypts or decrypts a plaintext value.
-	opAuthCrypt = 0x05 // Opens or seals a plaintext value.
-	opExpand    = 0x06 // Internal only. Derives up to 128 bits of PRF data from the protocol's transcript.
-	opRatchet   = 0x07 // Internal only. Replaces the protocol's transcript with 128 bits of derived data.
+	opInit         = 0x01 // Initializes a protocol with a domain separation string.
+	opMix          = 0x02 // Mixes a labeled input value into the protocol's state.
+	opDerive       = 0x03 // Derives pseudorandom data from the protocol's transcript.
+	opCrypt        = 0x04 // Encrypts or decrypts a plaintext value.
+	opAuthCrypt    = 0x05 // Opens or seals a plaintext value.
+	opExpand       = 0x06 // Internal only. Derives up to 128 bits of PRF data from the protocol's transcript.
+	opRatchet      = 0x07 // Internal only. Replaces the protocol's transcript with 128 bits of derived data.
+	opMixStream    = 0x08 // Mixes a labeled input stream of unknown length into the protocol's state.
+	opDeriveStream = 0x09 // Derives up to 64GiB of pseudorandom data from the protocol's transcript as a stream.
+	opCryptStream  = 0x0a // Encrypts or decrypts a plaintext stream of unknown length.
 )
 
 const (
-	maxExpandLen  = 16  // The length, in bytes, of the maximum data expandable from a transcript.
-	gcmNonceLen   = 12  // The length, in bytes, of an AES-GCM nonce.
-	bitsPerByte   = 8   // The number of bits in one byte.
-	initialBufLen = 128 // The length, in bytes, of the initial metadata buffer.
-	expandBufLen  = 32  // The length, in bytes, required of an expand buffer.
+	maxExpandLen  = 16                      // The length, in bytes, of the maximum data expandable from a transcript.
+	gcmNonceLen   = 12                      // The length, in bytes, of an AES-GCM nonce.
+	bitsPerByte   = 8                       // The number of bits in one byte.
+	initialBufLen = 128                     // The length, in bytes, of the initial metadata buffer.
+	expandBufLen  = 32                      // The length, in bytes, required of an expand buffer.
+	mixChunkLen   = 8 * 1024                // The length, in bytes, of the chunks a MixWriter writes to the transcript.
+	maxDeriveLen  = 64 * 1024 * 1024 * 1024 // The length, in bytes, of the maximum output of a Derive operation.
 )
 
 // noCopy is a fake lock used by -copylocks checker from `go vet`.
@@ -2,7 +2,9 @@ package lockstitch_test
 
 import (
 	"bytes"
+	"crypto/cipher"
 	"encoding/hex"
+	"io"
 	"testing"
 
 	"github.com/codahale/lockstitch-go"
@@ -62,6 +64,32 @@ func TestProtocol_AppendBinary(t *testing.T) {
 	}
 }
 
+func TestProtocol_OpenClearsOutput(t *testing.T) {
+	t.Parallel()
+
+	p := lockstitch.NewProtocol("example")
+	ciphertext := p.Seal("message", nil, []byte("this is an example"))
+	ciphertext[0] ^= 1
+
+	dst := make([]byte, 0, len(ciphertext))
+	if _, err := lockstitch.NewProtocol("example").Open("message", dst, ciphertext); err == nil {
+		t.Fatal("Open(modified ciphertext) did not return an error")
+	}
+
+	if out := dst[:len(ciphertext)-lockstitch.TagLen]; !bytes.Equal(out, make([]byte, len(out))) {
+		t.Errorf("Open left unauthenticated plaintext in dst: %x", out)
+	}
+
+	// When decrypting in place, the ciphertext is overwritten.
+	if _, err := lockstitch.NewProtocol("example").Open("message", ciphertext[:0], ciphertext); err == nil {
+		t.Fatal("Open(modified ciphertext) did not return an error")
+	}
+
+	if out := ciphertext[:len(ciphertext)-lockstitch.TagLen]; !bytes.Equal(out, make([]byte, len(out))) {
+		t.Errorf("Open left unauthenticated plaintext in place: %x", out)
+	}
+}
+
 func TestDeriveZeroOutputs(t *testing.T) {
 	t.Parallel()
 
@@ -115,3 +143,189 @@ func TestKnownAnswers(t *testing.T) {
 		t.Errorf("Derive('sixth') = %v, want = %v", got, want)
 	}
 }
+
+func TestProtocol_MixWriter(t *testing.T) {
+	t.Parallel()
+
+	input := make([]byte, 20_000)
+	for i := range input {
+		input[i] = byte(i)
+	}
+
+	mixStream := func(input []byte, writeLen int) []byte {
+		p := lockstitch.NewProtocol("example")
+		w := p.MixWriter("stream")
+		for len(input) > 0 {
+			n := min(writeLen, len(input))
+			if _, err := w.Write(input[:n]); err != nil {
+				t.Fatal(err)
+			}
+			input = input[n:]
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		return p.Derive("output", nil, 8)
+	}
+
+	want := mixStream(input, len(input))
+	for _, writeLen := range []int{1, 7, 4096, 8192, 8193} {
+		if got := mixStream(input, writeLen); !bytes.Equal(got, want) {
+			t.Errorf("MixWriter with %d-byte writes = %x, want = %x", writeLen, got, want)
+		}
+	}
+
+	p := lockstitch.NewProtocol("example")
+	p.Mix("stream", input)
+	if got := p.Derive("output", nil, 8); bytes.Equal(got, want) {
+		t.Errorf("Mix and MixWriter produced the same output: %x", got)
+	}
+
+	if got, want := hex.EncodeToString(mixStream(input[:10], 10)), "261c40fcf47f676b"; got != want {
+		t.Errorf("Derive('output') = %v, want = %v", got, want)
+	}
+}
+
+func TestMixWriter_Close(t *testing.T) {
+	t.Parallel()
+
+	p := lockstitch.NewProtocol("example")
+	w := p.MixWriter("stream")
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := w.Write([]byte("more")); err == nil {
+		t.Error("Write after Close did not return an error")
+	}
+}
+
+func TestProtocol_DeriveReader(t *testing.T) {
+	t.Parallel()
+
+	deriveStream := func(n, readLen int) (output, state []byte) {
+		p := lockstitch.NewProtocol("example")
+		r := p.DeriveReader("stream")
+		output = make([]byte, n)
+		for b := output; len(b) > 0; {
+			m, err := r.Read(b[:min(readLen, len(b))])
+			if err != nil {
+				t.Fatal(err)
+			}
+			b = b[m:]
+		}
+		if err := r.Close(); err != nil {
+			t.Fatal(err)
+		}
+		return output, p.Derive("state", nil, 8)
+	}
+
+	want, wantState := deriveStream(1000, 1000)
+	for _, readLen := range []int{1, 15, 16, 17, 512} {
+		got, gotState := deriveStream(1000, readLen)
+		if !bytes.Equal(got, want) {
+			t.Errorf("DeriveReader with %d-byte reads = %x, want = %x", readLen, got, want)
+		}
+		if !bytes.Equal(gotState, wantState) {
+			t.Errorf("divergent posterior protocol states: %x != %x", gotState, wantState)
+		}
+	}
+
+	prefix, prefixState := deriveStream(100, 100)
+	if !bytes.Equal(prefix, want[:100]) {
+		t.Errorf("DeriveReader output = %x, want prefix = %x", prefix, want[:100])
+	}
+	if bytes.Equal(prefixState, wantState) {
+		t.Errorf("posterior protocol states did not depend on output length: %x", prefixState)
+	}
+
+	p := lockstitch.NewProtocol("example")
+	if got := p.Derive("stream", nil, 100); bytes.Equal(got, prefix) {
+		t.Errorf("Derive and DeriveReader produced the same output: %x", got)
+	}
+
+	if got, want := hex.EncodeToString(want[:8]), "b1e70d9881c7ebe3"; got != want {
+		t.Errorf("DeriveReader('stream') = %v, want = %v", got, want)
+	}
+}
+
+func TestDeriveReader_Close(t *testing.T) {
+	t.Parallel()
+
+	p := lockstitch.NewProtocol("example")
+	r := p.DeriveReader("stream")
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := r.Read(make([]byte, 8)); err == nil {
+		t.Error("Read after Close did not return an error")
+	}
+}
+
+func TestProtocol_EncryptStream(t *testing.T) {
+	t.Parallel()
+
+	plaintext := bytes.Repeat([]byte("this is an example"), 100)
+
+	// Encrypt the plaintext via a cipher.StreamWriter.
+	p1 := lockstitch.NewProtocol("example")
+	ciphertext := new(bytes.Buffer)
+	w := cipher.StreamWriter{S: p1.EncryptStream("message"), W: ciphertext, Err: nil}
+	for _, piece := range [][]byte{plaintext[:7], plaintext[7:500], plaintext[500:]} {
+		if _, err := w.Write(piece); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.S.(*lockstitch.CryptStream).Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Decrypt the ciphertext via a cipher.StreamReader.
+	p2 := lockstitch.NewProtocol("example")
+	s := p2.DecryptStream("message")
+	got, err := io.ReadAll(cipher.StreamReader{S: s, R: bytes.NewReader(ciphertext.Bytes())})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(got, plaintext) {
+		t.Errorf("DecryptStream = %x, want = %x", got, plaintext)
+	}
+
+	stateA, stateB := p1.Derive("state", nil, 8), p2.Derive("state", nil, 8)
+	if !bytes.Equal(stateA, stateB) {
+		t.Errorf("divergent posterior protocol states: %x != %x", stateA, stateB)
+	}
+
+	p3 := lockstitch.NewProtocol("example")
+	if got := p3.Encrypt("message", nil, plaintext); bytes.Equal(got, ciphertext.Bytes()) {
+		t.Errorf("Encrypt and EncryptStream produced the same output: %x", got)
+	}
+
+	if got, want := hex.EncodeToString(stateA), "45e4d23baeaa2dd6"; got != want {
+		t.Errorf("Derive('state') = %v, want = %v", got, want)
+	}
+}
+
+func TestCryptStream_Close(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("The code did not panic")
+		}
+	}()
+
+	p := lockstitch.NewProtocol("example")
+	s := p.EncryptStream("message")
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	b := []byte("more")
+	s.XORKeyStream(b, b)
+}
@@ -0,0 +1,71 @@
+package lockstitch
+
+import (
+	"crypto/subtle"
+	"hash"
+)
+
+// MinMACTagLen is the minimum length, in bytes, of a MAC's tags.
+const MinMACTagLen = 16
+
+// A MAC calculates message authentication codes using a protocol. It implements hash.Hash, so it can be used in place
+// of e.g., HMAC.
+//
+// A MAC implements the streaming MAC construction in the design document: it is equivalent to initializing a protocol
+// with a domain separation string, mixing the key into the protocol using the label "key", mixing the message into the
+// protocol with a MixWriter using the label "message", and deriving the tag using the label "tag". Because the message
+// is mixed in as a stream, its tags differ from those of a protocol which mixes the message in with Mix.
+type MAC struct {
+	h *Hash
+}
+
+// NewMAC returns a MAC which calculates tags of tagLen bytes using the given domain separation string and key. As with
+// any protocol, the domain separation string ensures that tags calculated by different applications with the same key
+// are unrelated. TagLen is a reasonable default tag length.
+//
+// NewMAC panics if tagLen is less than MinMACTagLen.
+func NewMAC(domain string, key []byte, tagLen int) *MAC {
+	if tagLen < MinMACTagLen {
+		panic("invalid argument to NewMAC: tagLen must be >= MinMACTagLen")
+	}
+
+	p := NewProtocol(domain)
+	p.Mix("key", key)
+
+	return &MAC{h: newHash(domain, p, "message", "tag", tagLen)}
+}
+
+// Write mixes more data into the running MAC. It never returns an error.
+func (m *MAC) Write(b []byte) (int, error) {
+	return m.h.Write(b)
+}
+
+// Sum appends the current tag to b and returns the resulting slice. It does not change the underlying MAC state.
+func (m *MAC) Sum(b []byte) []byte {
+	return m.h.Sum(b)
+}
+
+// Verify returns true if the given tag is the tag of the data written to the MAC. It compares the tags in constant time
+// and does not change the underlying MAC state.
+func (m *MAC) Verify(tag []byte) bool {
+	var buf [TagLen * 2]byte
+	return subtle.ConstantTimeCompare(tag, m.h.Sum(buf[:0])) == 1
+}
+
+// Reset resets the MAC to its initial state.
+func (m *MAC) Reset() {
+	m.h.Reset()
+}
+
+// Size returns the number of bytes Sum will return.
+func (m *MAC) Size() int {
+	return m.h.Size()
+}
+
+// BlockSize returns the size of the chunks into which the message is split. Writes which are multiples of the block
+// size are not buffered.
+func (m *MAC) BlockSize() int {
+	return m.h.BlockSize()
+}
+
+var _ hash.Hash = (*MAC)(nil)
@@ -0,0 +1,109 @@
+package lockstitch_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/codahale/lockstitch-go"
+)
+
+func TestMAC(t *testing.T) {
+	t.Parallel()
+
+	key := []byte("yellow submarine")
+	message := []byte("this is an example")
+
+	m := lockstitch.NewMAC("com.example.mac", key, lockstitch.TagLen)
+	_, _ = m.Write(message[:4])
+	_, _ = m.Write(message[4:])
+	tag := m.Sum(nil)
+
+	if got, want := len(tag), lockstitch.TagLen; got != want {
+		t.Errorf("len(Sum) = %d, want = %d", got, want)
+	}
+
+	if got, want := hex.EncodeToString(tag), "fa1f7b2a6fa8ebf0b1587bcdbd7b0d6a"; got != want {
+		t.Errorf("Sum = %v, want = %v", got, want)
+	}
+
+	if !m.Verify(tag) {
+		t.Error("Verify(tag) = false, want = true")
+	}
+
+	if m.Verify(tag[:len(tag)-1]) {
+		t.Error("Verify(truncated tag) = true, want = false")
+	}
+
+	badTag := bytes.Clone(tag)
+	badTag[0] ^= 1
+	if m.Verify(badTag) {
+		t.Error("Verify(modified tag) = true, want = false")
+	}
+
+	other := lockstitch.NewMAC("com.example.mac", []byte("other key"), lockstitch.TagLen)
+	_, _ = other.Write(message)
+	if other.Verify(tag) {
+		t.Error("Verify(tag) with other key = true, want = false")
+	}
+
+	m.Reset()
+	if m.Verify(tag) {
+		t.Error("Verify(tag) after Reset = true, want = false")
+	}
+}
+
+func TestMAC_Construction(t *testing.T) {
+	t.Parallel()
+
+	key := []byte("yellow submarine")
+	message := []byte("this is an example")
+
+	// A MAC is equivalent to the streaming MAC construction from the design document.
+	p := lockstitch.NewProtocol("com.example.mac")
+	p.Mix("key", key)
+	w := p.MixWriter("message")
+	_, _ = w.Write(message)
+	_ = w.Close()
+	want := p.Derive("tag", nil, lockstitch.TagLen)
+
+	m := lockstitch.NewMAC("com.example.mac", key, lockstitch.TagLen)
+	_, _ = m.Write(message)
+
+	if got := m.Sum(nil); !bytes.Equal(got, want) {
+		t.Errorf("Sum = %x, want = %x", got, want)
+	}
+
+	// It is not equivalent to the one-shot MAC construction.
+	p = lockstitch.NewProtocol("com.example.mac")
+	p.Mix("key", key)
+	p.Mix("message", message)
+	if m.Verify(p.Derive("tag", nil, lockstitch.TagLen)) {
+		t.Error("Verify(one-shot tag) = true, want = false")
+	}
+}
+
+func TestMAC_TagLen(t *testing.T) {
+	t.Parallel()
+
+	m := lockstitch.NewMAC("com.example.mac", []byte("key"), 32)
+	if got, want := len(m.Sum(nil)), 32; got != want {
+		t.Errorf("len(Sum) = %d, want = %d", got, want)
+	}
+
+	if got, want := m.Size(), 32; got != want {
+		t.Errorf("Size = %d, want = %d", got, want)
+	}
+}
+
+func TestMAC_MinTagLen(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("The code did not panic")
+		}
+	}()
+
+	lockstitch.NewMAC("com.example.mac", []byte("key"), lockstitch.MinMACTagLen-1)
+}
@@ -0,0 +1,24 @@
+//go:build go1.26
+
+package pqhpke
+
+import (
+	"crypto/ecdh"
+	"crypto/mlkem/mlkemtest"
+)
+
+// EncryptDeterministic encrypts the plaintext using the given ML-KEM-768 encapsulation randomness and ephemeral X25519
+// private key, for use in known-answer tests.
+func EncryptDeterministic(receiver *PublicKey, plaintext, mlkemRandom, ephemeral []byte) ([]byte, error) {
+	mlkemSS, mlkemCT, err := mlkemtest.Encapsulate768(receiver.mlkem, mlkemRandom)
+	if err != nil {
+		return nil, err
+	}
+
+	e, err := ecdh.X25519().NewPrivateKey(ephemeral)
+	if err != nil {
+		return nil, err
+	}
+
+	return encrypt(receiver, plaintext, mlkemSS, mlkemCT, e)
+}
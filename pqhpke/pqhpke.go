@@ -0,0 +1,204 @@
+// Package pqhpke implements a post-quantum hybrid public-key encryption scheme using Lockstitch, ML-KEM-768 via
+// crypto/mlkem, and X25519 via crypto/ecdh.
+//
+// Each message is encapsulated with both ML-KEM-768 and an ephemeral X25519 key pair. Both ciphertexts, both of the
+// receiver's public keys, and both shared secrets are mixed into a protocol, which then seals the message. As a
+// result, the scheme remains secure as long as either ML-KEM-768 or X25519 remains secure.
+//
+// A private key is encoded as the 64-byte ML-KEM-768 seed followed by the 32-byte X25519 private key, for a total of
+// PrivateKeySize bytes. A public key is encoded as the 1184-byte ML-KEM-768 encapsulation key followed by the 32-byte
+// X25519 public key, for a total of PublicKeySize bytes. A ciphertext is encoded as the 1088-byte ML-KEM-768 ciphertext,
+// followed by the 32-byte ephemeral X25519 public key, followed by the sealed plaintext and its authentication tag, for
+// a total of Overhead bytes more than the plaintext.
+package pqhpke
+
+import (
+	"crypto/ecdh"
+	"crypto/mlkem"
+	"crypto/rand"
+	"errors"
+
+	"github.com/codahale/lockstitch-go"
+)
+
+const (
+	// PrivateKeySize is the size, in bytes, of an encoded private key.
+	PrivateKeySize = mlkem.SeedSize + x25519KeySize
+
+	// PublicKeySize is the size, in bytes, of an encoded public key.
+	PublicKeySize = mlkem.EncapsulationKeySize768 + x25519KeySize
+
+	// Overhead is the difference, in bytes, between the length of a ciphertext and its plaintext.
+	Overhead = mlkem.CiphertextSize768 + x25519KeySize + lockstitch.TagLen
+)
+
+var (
+	// ErrInvalidPrivateKey is returned when an encoded private key is invalid.
+	ErrInvalidPrivateKey = errors.New("pqhpke: invalid private key")
+
+	// ErrInvalidPublicKey is returned when an encoded public key is invalid.
+	ErrInvalidPublicKey = errors.New("pqhpke: invalid public key")
+)
+
+// A PrivateKey is a hybrid ML-KEM-768 and X25519 private key.
+type PrivateKey struct {
+	mlkem  *mlkem.DecapsulationKey768
+	x25519 *ecdh.PrivateKey
+}
+
+// GenerateKey generates a new, random private key.
+func GenerateKey() (*PrivateKey, error) {
+	dk, err := mlkem.GenerateKey768()
+	if err != nil {
+		return nil, err
+	}
+
+	x, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PrivateKey{mlkem: dk, x25519: x}, nil
+}
+
+// NewPrivateKey parses an encoded private key.
+func NewPrivateKey(b []byte) (*PrivateKey, error) {
+	if len(b) != PrivateKeySize {
+		return nil, ErrInvalidPrivateKey
+	}
+
+	dk, err := mlkem.NewDecapsulationKey768(b[:mlkem.SeedSize])
+	if err != nil {
+		return nil, ErrInvalidPrivateKey
+	}
+
+	x, err := ecdh.X25519().NewPrivateKey(b[mlkem.SeedSize:])
+	if err != nil {
+		return nil, ErrInvalidPrivateKey
+	}
+
+	return &PrivateKey{mlkem: dk, x25519: x}, nil
+}
+
+// Bytes returns the encoded private key.
+func (k *PrivateKey) Bytes() []byte {
+	b := make([]byte, 0, PrivateKeySize)
+	b = append(b, k.mlkem.Bytes()...)
+	return append(b, k.x25519.Bytes()...)
+}
+
+// PublicKey returns the public key corresponding to the private key.
+func (k *PrivateKey) PublicKey() *PublicKey {
+	return &PublicKey{mlkem: k.mlkem.EncapsulationKey(), x25519: k.x25519.PublicKey()}
+}
+
+// A PublicKey is a hybrid ML-KEM-768 and X25519 public key.
+type PublicKey struct {
+	mlkem  *mlkem.EncapsulationKey768
+	x25519 *ecdh.PublicKey
+}
+
+// NewPublicKey parses an encoded public key.
+func NewPublicKey(b []byte) (*PublicKey, error) {
+	if len(b) != PublicKeySize {
+		return nil, ErrInvalidPublicKey
+	}
+
+	ek, err := mlkem.NewEncapsulationKey768(b[:mlkem.EncapsulationKeySize768])
+	if err != nil {
+		return nil, ErrInvalidPublicKey
+	}
+
+	x, err := ecdh.X25519().NewPublicKey(b[mlkem.EncapsulationKeySize768:])
+	if err != nil {
+		return nil, ErrInvalidPublicKey
+	}
+
+	return &PublicKey{mlkem: ek, x25519: x}, nil
+}
+
+// Bytes returns the encoded public key.
+func (k *PublicKey) Bytes() []byte {
+	b := make([]byte, 0, PublicKeySize)
+	b = append(b, k.mlkem.Bytes()...)
+	return append(b, k.x25519.Bytes()...)
+}
+
+// Encrypt encrypts the plaintext for the given receiver's public key and returns the ciphertext.
+func Encrypt(receiver *PublicKey, plaintext []byte) ([]byte, error) {
+	// Encapsulate a shared secret with ML-KEM-768.
+	mlkemSS, mlkemCT := receiver.mlkem.Encapsulate()
+
+	// Generate an ephemeral X25519 key pair.
+	ephemeral, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	return encrypt(receiver, plaintext, mlkemSS, mlkemCT, ephemeral)
+}
+
+// Decrypt decrypts the ciphertext using the given receiver's private key. If the ciphertext is invalid, it returns
+// lockstitch.ErrInvalidCiphertext.
+func Decrypt(receiver *PrivateKey, ciphertext []byte) ([]byte, error) {
+	if len(ciphertext) < Overhead {
+		return nil, lockstitch.ErrInvalidCiphertext
+	}
+
+	mlkemCT := ciphertext[:mlkem.CiphertextSize768]
+	ephemeralPub := ciphertext[mlkem.CiphertextSize768 : mlkem.CiphertextSize768+x25519KeySize]
+	ciphertext = ciphertext[mlkem.CiphertextSize768+x25519KeySize:]
+
+	// Decapsulate the ML-KEM-768 shared secret.
+	mlkemSS, err := receiver.mlkem.Decapsulate(mlkemCT)
+	if err != nil {
+		return nil, lockstitch.ErrInvalidCiphertext
+	}
+
+	// Calculate the X25519 shared secret.
+	ephemeral, err := ecdh.X25519().NewPublicKey(ephemeralPub)
+	if err != nil {
+		return nil, lockstitch.ErrInvalidCiphertext
+	}
+
+	x25519SS, err := receiver.x25519.ECDH(ephemeral)
+	if err != nil {
+		return nil, lockstitch.ErrInvalidCiphertext
+	}
+
+	p := newProtocol(receiver.PublicKey(), mlkemCT, ephemeralPub, mlkemSS, x25519SS)
+	return p.Open("message", nil, ciphertext)
+}
+
+// encrypt encrypts the plaintext using the given ML-KEM-768 encapsulation and ephemeral X25519 key pair.
+func encrypt(receiver *PublicKey, plaintext, mlkemSS, mlkemCT []byte, ephemeral *ecdh.PrivateKey) ([]byte, error) {
+	// Calculate the X25519 shared secret.
+	x25519SS, err := ephemeral.ECDH(receiver.x25519)
+	if err != nil {
+		return nil, err
+	}
+
+	ephemeralPub := ephemeral.PublicKey().Bytes()
+	p := newProtocol(receiver, mlkemCT, ephemeralPub, mlkemSS, x25519SS)
+
+	// Seal the plaintext, appending it to the ML-KEM-768 ciphertext and the ephemeral public key.
+	out := make([]byte, 0, len(plaintext)+Overhead)
+	out = append(out, mlkemCT...)
+	out = append(out, ephemeralPub...)
+	return p.Seal("message", out, plaintext), nil
+}
+
+// newProtocol returns a protocol with the receiver's public keys, the ciphertexts, and the shared secrets mixed in.
+func newProtocol(receiver *PublicKey, mlkemCT, ephemeralPub, mlkemSS, x25519SS []byte) *lockstitch.Protocol {
+	p := lockstitch.NewProtocol("lockstitch-go.pqhpke")
+	p.Mix("receiver ml-kem-768", receiver.mlkem.Bytes())
+	p.Mix("receiver x25519", receiver.x25519.Bytes())
+	p.Mix("ml-kem-768 ciphertext", mlkemCT)
+	p.Mix("ephemeral x25519", ephemeralPub)
+	p.Mix("ml-kem-768 shared secret", mlkemSS)
+	p.Mix("x25519 shared secret", x25519SS)
+	return p
+}
+
+// x25519KeySize is the size, in bytes, of X25519 private and public keys.
+const x25519KeySize = 32
@@ -0,0 +1,103 @@
+package pqhpke_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/codahale/lockstitch-go"
+	"github.com/codahale/lockstitch-go/pqhpke"
+)
+
+func TestEncrypt(t *testing.T) {
+	t.Parallel()
+
+	receiver := generateKey(t)
+	plaintext := []byte("this is an example")
+
+	ciphertext, err := pqhpke.Encrypt(receiver.PublicKey(), plaintext)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := len(ciphertext), len(plaintext)+pqhpke.Overhead; got != want {
+		t.Errorf("len(ciphertext) = %d, want = %d", got, want)
+	}
+
+	got, err := pqhpke.Decrypt(receiver, ciphertext)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(got, plaintext) {
+		t.Errorf("Decrypt = %x, want = %x", got, plaintext)
+	}
+
+	if _, err := pqhpke.Decrypt(generateKey(t), ciphertext); !errors.Is(err, lockstitch.ErrInvalidCiphertext) {
+		t.Errorf("Decrypt(wrong receiver) = %v, want = %v", err, lockstitch.ErrInvalidCiphertext)
+	}
+
+	if _, err := pqhpke.Decrypt(receiver, ciphertext[:pqhpke.Overhead-1]); !errors.Is(err, lockstitch.ErrInvalidCiphertext) {
+		t.Errorf("Decrypt(short ciphertext) = %v, want = %v", err, lockstitch.ErrInvalidCiphertext)
+	}
+
+	// Modify the ML-KEM-768 ciphertext, the ephemeral X25519 public key, the sealed plaintext, and the tag.
+	for _, i := range []int{0, 1087, 1088, 1119, 1120, len(ciphertext) - 1} {
+		modified := bytes.Clone(ciphertext)
+		modified[i] ^= 1
+		if _, err := pqhpke.Decrypt(receiver, modified); !errors.Is(err, lockstitch.ErrInvalidCiphertext) {
+			t.Errorf("Decrypt(ciphertext with byte %d modified) = %v, want = %v", i, err, lockstitch.ErrInvalidCiphertext)
+		}
+	}
+}
+
+func TestKeySerialization(t *testing.T) {
+	t.Parallel()
+
+	k := generateKey(t)
+
+	sk, err := pqhpke.NewPrivateKey(k.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := sk.Bytes(), k.Bytes(); !bytes.Equal(got, want) {
+		t.Errorf("NewPrivateKey(k.Bytes()).Bytes() = %x, want = %x", got, want)
+	}
+
+	pk, err := pqhpke.NewPublicKey(k.PublicKey().Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := pk.Bytes(), k.PublicKey().Bytes(); !bytes.Equal(got, want) {
+		t.Errorf("NewPublicKey(pk.Bytes()).Bytes() = %x, want = %x", got, want)
+	}
+
+	if got, want := len(k.Bytes()), pqhpke.PrivateKeySize; got != want {
+		t.Errorf("len(PrivateKey.Bytes()) = %d, want = %d", got, want)
+	}
+
+	if got, want := len(pk.Bytes()), pqhpke.PublicKeySize; got != want {
+		t.Errorf("len(PublicKey.Bytes()) = %d, want = %d", got, want)
+	}
+
+	if _, err := pqhpke.NewPrivateKey(k.Bytes()[1:]); !errors.Is(err, pqhpke.ErrInvalidPrivateKey) {
+		t.Errorf("NewPrivateKey(short key) = %v, want = %v", err, pqhpke.ErrInvalidPrivateKey)
+	}
+
+	if _, err := pqhpke.NewPublicKey(pk.Bytes()[1:]); !errors.Is(err, pqhpke.ErrInvalidPublicKey) {
+		t.Errorf("NewPublicKey(short key) = %v, want = %v", err, pqhpke.ErrInvalidPublicKey)
+	}
+}
+
+func generateKey(tb testing.TB) *pqhpke.PrivateKey {
+	tb.Helper()
+
+	k, err := pqhpke.GenerateKey()
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	return k
+}
@@ -0,0 +1,33 @@
+//go:build go1.26
+
+package pqhpke_test
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/codahale/lockstitch-go/pqhpke"
+)
+
+// TestVectors_Encrypt checks encryption against the known-answer tests. Deterministic ML-KEM encapsulation requires
+// crypto/mlkem/mlkemtest, which was added in Go 1.26.
+func TestVectors_Encrypt(t *testing.T) {
+	t.Parallel()
+
+	for i, v := range loadVectors(t) {
+		k, err := pqhpke.NewPrivateKey(mustDecodeHex(t, v.PrivateKey))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ciphertext, err := pqhpke.EncryptDeterministic(k.PublicKey(), mustDecodeHex(t, v.Plaintext),
+			mustDecodeHex(t, v.MLKEMRandom), mustDecodeHex(t, v.Ephemeral))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got, want := hex.EncodeToString(ciphertext), v.Ciphertext; got != want {
+			t.Errorf("vector %d: Encrypt = %v, want = %v", i, got, want)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package pqhpke_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/codahale/lockstitch-go/pqhpke"
+)
+
+// TestVectors checks key derivation and decryption against the known-answer tests. Encryption is checked by
+// TestVectors_Encrypt, which requires crypto/mlkem/mlkemtest.
+func TestVectors(t *testing.T) {
+	t.Parallel()
+
+	for i, v := range loadVectors(t) {
+		k, err := pqhpke.NewPrivateKey(mustDecodeHex(t, v.PrivateKey))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got, want := hex.EncodeToString(k.PublicKey().Bytes()), v.PublicKey; got != want {
+			t.Errorf("vector %d: PublicKey = %v, want = %v", i, got, want)
+		}
+
+		got, err := pqhpke.Decrypt(k, mustDecodeHex(t, v.Ciphertext))
+		if err != nil {
+			t.Fatalf("vector %d: Decrypt = %v", i, err)
+		}
+
+		if want := mustDecodeHex(t, v.Plaintext); !bytes.Equal(got, want) {
+			t.Errorf("vector %d: Decrypt = %x, want = %x", i, got, want)
+		}
+	}
+}
+
+type vector struct {
+	PrivateKey  string `json:"private_key"`
+	PublicKey   string `json:"public_key"`
+	MLKEMRandom string `json:"mlkem_random"`
+	Ephemeral   string `json:"ephemeral"`
+	Plaintext   string `json:"plaintext"`
+	Ciphertext  string `json:"ciphertext"`
+}
+
+func loadVectors(tb testing.TB) []vector {
+	tb.Helper()
+
+	data, err := os.ReadFile("testdata/vectors.json")
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	var vectors []vector
+	if err := json.Unmarshal(data, &vectors); err != nil {
+		tb.Fatal(err)
+	}
+
+	return vectors
+}
+
+func mustDecodeHex(tb testing.TB, s string) []byte {
+	tb.Helper()
+
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	return b
+}
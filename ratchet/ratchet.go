@@ -0,0 +1,483 @@
+// Package ratchet implements the Double Ratchet algorithm using Lockstitch protocols as KDF chains and X25519 via
+// crypto/ecdh for the Diffie-Hellman ratchet.
+//
+// A Session has a root chain, a sending chain, and a receiving chain, each of which is a protocol. Each time a party
+// receives a new ratchet public key from the other party, it mixes the resulting ECDH shared secrets into the root
+// chain and derives new receiving and sending chain keys from it, providing break-in recovery. Each message is
+// encrypted with a key derived from the sending or receiving chain, which ratchets the chain and provides forward
+// security.
+//
+// A message consists of a header, containing the sender's current ratchet public key (32 bytes), the big-endian 32-bit
+// length of the sender's previous sending chain, and the big-endian 32-bit index of the message in the current sending
+// chain, followed by the sealed plaintext. The header is not encrypted, but is authenticated.
+//
+// Messages may arrive out of order. The keys of messages which have been skipped are cached so that they can be
+// decrypted when they arrive. To limit the resources an adversary can consume, at most MaxSkip messages can be skipped
+// in a single chain and at most MaxSkippedKeys keys are cached, with the oldest keys being discarded first.
+package ratchet
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding"
+	"encoding/binary"
+	"errors"
+	"maps"
+	"slices"
+
+	"github.com/codahale/lockstitch-go"
+)
+
+const (
+	// MaxSkip is the maximum number of messages which can be skipped in a single receiving chain.
+	MaxSkip = 1000
+
+	// MaxSkippedKeys is the maximum number of skipped message keys which are cached.
+	MaxSkippedKeys = 2000
+
+	// HeaderSize is the size, in bytes, of a message header.
+	HeaderSize = publicKeyLen + 4 + 4
+
+	// Overhead is the difference, in bytes, between the length of a message and its plaintext.
+	Overhead = HeaderSize + lockstitch.TagLen
+)
+
+var (
+	// ErrUnsupportedCurve is returned when a key uses a curve other than X25519.
+	ErrUnsupportedCurve = errors.New("ratchet: unsupported curve")
+
+	// ErrTooManySkipped is returned when a message would require skipping more than MaxSkip messages.
+	ErrTooManySkipped = errors.New("ratchet: too many skipped messages")
+
+	// ErrNoSendingChain is returned when a responder attempts to encrypt a message before receiving one.
+	ErrNoSendingChain = errors.New("ratchet: no sending chain")
+
+	errInvalidState = errors.New("ratchet: invalid session state")
+)
+
+// A Session is one party's state of a Double Ratchet session.
+type Session struct {
+	root      *lockstitch.Protocol
+	send      *lockstitch.Protocol
+	recv      *lockstitch.Protocol
+	self      *ecdh.PrivateKey
+	remote    *ecdh.PublicKey
+	ns, nr    uint32
+	pn        uint32
+	skipped   map[skippedKey][]byte
+	skipOrder []skippedKey
+}
+
+// NewInitiator returns a Session for the party which sends the first message, given a shared secret agreed upon with
+// the responder (e.g., via a handshake) and the responder's ratchet public key.
+func NewInitiator(sharedSecret []byte, remote *ecdh.PublicKey) (*Session, error) {
+	if remote.Curve() != ecdh.X25519() {
+		return nil, ErrUnsupportedCurve
+	}
+
+	self, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	s := newSession(sharedSecret, self)
+	s.remote = remote
+	if err := s.mixDH(); err != nil {
+		return nil, err
+	}
+	s.send = s.deriveChain()
+
+	return s, nil
+}
+
+// NewResponder returns a Session for the party which receives the first message, given a shared secret agreed upon
+// with the initiator and the responder's ratchet private key. A responder cannot encrypt messages until it has
+// decrypted one.
+func NewResponder(sharedSecret []byte, self *ecdh.PrivateKey) (*Session, error) {
+	if self.Curve() != ecdh.X25519() {
+		return nil, ErrUnsupportedCurve
+	}
+
+	return newSession(sharedSecret, self), nil
+}
+
+// Encrypt encrypts the plaintext and authenticates it along with the associated data, and returns the message.
+func (s *Session) Encrypt(plaintext, associatedData []byte) ([]byte, error) {
+	if s.send == nil {
+		return nil, ErrNoSendingChain
+	}
+
+	header := make([]byte, 0, len(plaintext)+Overhead)
+	header = append(header, s.self.PublicKey().Bytes()...)
+	header = binary.BigEndian.AppendUint32(header, s.pn)
+	header = binary.BigEndian.AppendUint32(header, s.ns)
+
+	mk := messageKey(s.send)
+	defer clear(mk)
+	s.ns++
+
+	return newMessageProtocol(mk, header, associatedData).Seal("message", header, plaintext), nil
+}
+
+// Decrypt decrypts and authenticates the message along with the associated data, and returns the plaintext. If the
+// message is invalid, Decrypt returns lockstitch.ErrInvalidCiphertext and the session's state is unchanged.
+func (s *Session) Decrypt(message, associatedData []byte) ([]byte, error) {
+	if len(message) < Overhead {
+		return nil, lockstitch.ErrInvalidCiphertext
+	}
+
+	header, ciphertext := message[:HeaderSize], message[HeaderSize:]
+	pn := binary.BigEndian.Uint32(header[publicKeyLen:])
+	n := binary.BigEndian.Uint32(header[publicKeyLen+4:])
+
+	// Check for a skipped message key.
+	key := skippedKey{pub: [publicKeyLen]byte(header[:publicKeyLen]), n: n}
+	if mk, ok := s.skipped[key]; ok {
+		plaintext, err := newMessageProtocol(mk, header, associatedData).Open("message", nil, ciphertext)
+		if err != nil {
+			return nil, err
+		}
+
+		delete(s.skipped, key)
+		s.skipOrder = slices.DeleteFunc(s.skipOrder, func(k skippedKey) bool { return k == key })
+		clear(mk)
+
+		return plaintext, nil
+	}
+
+	// Update a copy of the session's state, in case the message is invalid.
+	next := s.clone()
+
+	if next.remote == nil || !bytes.Equal(header[:publicKeyLen], next.remote.Bytes()) {
+		remote, err := ecdh.X25519().NewPublicKey(header[:publicKeyLen])
+		if err != nil {
+			return nil, lockstitch.ErrInvalidCiphertext
+		}
+
+		if err := next.skip(pn); err != nil {
+			return nil, err
+		}
+
+		if err := next.dhRatchet(remote); err != nil {
+			return nil, err
+		}
+	}
+
+	// An initiator has no receiving chain until the responder's first message ratchets in a new public key.
+	if next.recv == nil {
+		return nil, lockstitch.ErrInvalidCiphertext
+	}
+
+	if err := next.skip(n); err != nil {
+		return nil, err
+	}
+
+	mk := messageKey(next.recv)
+	defer clear(mk)
+	next.nr++
+
+	plaintext, err := newMessageProtocol(mk, header, associatedData).Open("message", nil, ciphertext)
+	if err != nil {
+		return nil, err
+	}
+
+	*s = *next
+
+	return plaintext, nil
+}
+
+// MarshalBinary returns the session's state. The state contains secret keys and must be stored securely.
+func (s *Session) MarshalBinary() ([]byte, error) {
+	return s.AppendBinary(nil)
+}
+
+// AppendBinary appends the session's state to b and returns the resulting slice.
+func (s *Session) AppendBinary(b []byte) ([]byte, error) {
+	b = append(b, sessionVersion)
+
+	for _, p := range []*lockstitch.Protocol{s.root, s.send, s.recv} {
+		if p == nil {
+			b = binary.BigEndian.AppendUint32(b, 0)
+			continue
+		}
+
+		state, err := p.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+
+		b = binary.BigEndian.AppendUint32(b, uint32(len(state))) //nolint:gosec // state is always small
+		b = append(b, state...)
+	}
+
+	b = append(b, s.self.Bytes()...)
+	if s.remote != nil {
+		b = append(b, 1)
+		b = append(b, s.remote.Bytes()...)
+	} else {
+		b = append(b, 0)
+	}
+
+	b = binary.BigEndian.AppendUint32(b, s.ns)
+	b = binary.BigEndian.AppendUint32(b, s.nr)
+	b = binary.BigEndian.AppendUint32(b, s.pn)
+
+	b = binary.BigEndian.AppendUint32(b, uint32(len(s.skipOrder))) //nolint:gosec // bounded by MaxSkippedKeys
+	for _, k := range s.skipOrder {
+		b = append(b, k.pub[:]...)
+		b = binary.BigEndian.AppendUint32(b, k.n)
+		b = append(b, s.skipped[k]...)
+	}
+
+	return b, nil
+}
+
+// UnmarshalBinary restores a state previously returned by MarshalBinary.
+func (s *Session) UnmarshalBinary(data []byte) error {
+	r := reader{data: data, err: nil}
+	if r.byte() != sessionVersion {
+		return errInvalidState
+	}
+
+	var protocols [3]*lockstitch.Protocol
+	for i := range protocols {
+		n := r.uint32()
+		if n == 0 {
+			continue
+		}
+
+		if n > maxProtocolStateLen {
+			return errInvalidState
+		}
+
+		var p lockstitch.Protocol
+		if err := p.UnmarshalBinary(r.bytes(int(n))); err != nil && r.err == nil {
+			r.err = errInvalidState
+		}
+		protocols[i] = &p
+	}
+
+	self, err := ecdh.X25519().NewPrivateKey(r.bytes(publicKeyLen))
+	if err != nil && r.err == nil {
+		r.err = errInvalidState
+	}
+
+	var remote *ecdh.PublicKey
+	if r.byte() == 1 {
+		remote, err = ecdh.X25519().NewPublicKey(r.bytes(publicKeyLen))
+		if err != nil && r.err == nil {
+			r.err = errInvalidState
+		}
+	}
+
+	ns, nr, pn := r.uint32(), r.uint32(), r.uint32()
+
+	count := r.uint32()
+	if count > MaxSkippedKeys {
+		return errInvalidState
+	}
+
+	skipped := make(map[skippedKey][]byte, count)
+	skipOrder := make([]skippedKey, 0, count)
+	for range count {
+		k := skippedKey{pub: [publicKeyLen]byte(r.bytes(publicKeyLen)), n: r.uint32()}
+		skipped[k] = bytes.Clone(r.bytes(messageKeyLen))
+		skipOrder = append(skipOrder, k)
+	}
+
+	if r.err != nil || len(r.data) != 0 || protocols[0] == nil {
+		return errInvalidState
+	}
+
+	*s = Session{
+		root:      protocols[0],
+		send:      protocols[1],
+		recv:      protocols[2],
+		self:      self,
+		remote:    remote,
+		ns:        ns,
+		nr:        nr,
+		pn:        pn,
+		skipped:   skipped,
+		skipOrder: skipOrder,
+	}
+
+	return nil
+}
+
+// dhRatchet performs a Diffie-Hellman ratchet step with the other party's new ratchet public key.
+func (s *Session) dhRatchet(remote *ecdh.PublicKey) error {
+	s.pn, s.ns, s.nr = s.ns, 0, 0
+	s.remote = remote
+
+	// Derive a new receiving chain using our current key pair.
+	if err := s.mixDH(); err != nil {
+		return err
+	}
+	s.recv = s.deriveChain()
+
+	// Generate a new key pair and derive a new sending chain.
+	self, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return err
+	}
+	s.self = self
+
+	if err := s.mixDH(); err != nil {
+		return err
+	}
+	s.send = s.deriveChain()
+
+	return nil
+}
+
+// skip caches the message keys of the receiving chain up to but not including the message with index n.
+func (s *Session) skip(n uint32) error {
+	if s.recv == nil {
+		return nil
+	}
+
+	if n < s.nr {
+		// The message is from the past and its key has already been used or discarded.
+		return lockstitch.ErrInvalidCiphertext
+	}
+
+	if n-s.nr > MaxSkip {
+		return ErrTooManySkipped
+	}
+
+	pub := [publicKeyLen]byte(s.remote.Bytes())
+	for ; s.nr < n; s.nr++ {
+		// Discard the oldest skipped key if the cache is full.
+		if len(s.skipOrder) == MaxSkippedKeys {
+			delete(s.skipped, s.skipOrder[0])
+			s.skipOrder = s.skipOrder[1:]
+		}
+
+		k := skippedKey{pub: pub, n: s.nr}
+		s.skipped[k] = messageKey(s.recv)
+		s.skipOrder = append(s.skipOrder, k)
+	}
+
+	return nil
+}
+
+// mixDH mixes the ECDH shared secret of our ratchet private key and the other party's ratchet public key into the root
+// chain.
+func (s *Session) mixDH() error {
+	ss, err := s.self.ECDH(s.remote)
+	if err != nil {
+		return lockstitch.ErrInvalidCiphertext
+	}
+	defer clear(ss)
+
+	s.root.Mix("dh", ss)
+
+	return nil
+}
+
+// deriveChain derives a new chain key from the root chain and returns a protocol keyed with it.
+func (s *Session) deriveChain() *lockstitch.Protocol {
+	ck := s.root.Derive("chain key", nil, chainKeyLen)
+	defer clear(ck)
+
+	chain := lockstitch.NewProtocol("lockstitch-go.ratchet.chain")
+	chain.Mix("chain key", ck)
+
+	return chain
+}
+
+// clone returns a deep copy of the session's state.
+func (s *Session) clone() *Session {
+	c := *s
+	c.root = s.root.Clone()
+	if s.send != nil {
+		c.send = s.send.Clone()
+	}
+	if s.recv != nil {
+		c.recv = s.recv.Clone()
+	}
+	c.skipped = maps.Clone(s.skipped)
+	c.skipOrder = slices.Clone(s.skipOrder)
+
+	return &c
+}
+
+func newSession(sharedSecret []byte, self *ecdh.PrivateKey) *Session {
+	root := lockstitch.NewProtocol("lockstitch-go.ratchet")
+	root.Mix("shared secret", sharedSecret)
+
+	return &Session{
+		root:      root,
+		send:      nil,
+		recv:      nil,
+		self:      self,
+		remote:    nil,
+		ns:        0,
+		nr:        0,
+		pn:        0,
+		skipped:   make(map[skippedKey][]byte),
+		skipOrder: nil,
+	}
+}
+
+// messageKey derives the next message key from the chain, ratcheting the chain's state.
+func messageKey(chain *lockstitch.Protocol) []byte {
+	return chain.Derive("message key", nil, messageKeyLen)
+}
+
+// newMessageProtocol returns a protocol for sealing or opening a single message.
+func newMessageProtocol(mk, header, associatedData []byte) *lockstitch.Protocol {
+	p := lockstitch.NewProtocol("lockstitch-go.ratchet.message")
+	p.Mix("message key", mk)
+	p.Mix("header", header)
+	p.Mix("associated data", associatedData)
+	return p
+}
+
+// A skippedKey identifies a skipped message by the sender's ratchet public key and the message's index.
+type skippedKey struct {
+	pub [publicKeyLen]byte
+	n   uint32
+}
+
+// A reader reads fields from a marshaled session state, recording the first error.
+type reader struct {
+	data []byte
+	err  error
+}
+
+func (r *reader) bytes(n int) []byte {
+	if r.err != nil || len(r.data) < n {
+		r.err = errInvalidState
+		return make([]byte, n)
+	}
+
+	b := r.data[:n]
+	r.data = r.data[n:]
+	return b
+}
+
+func (r *reader) byte() byte {
+	return r.bytes(1)[0]
+}
+
+func (r *reader) uint32() uint32 {
+	return binary.BigEndian.Uint32(r.bytes(4))
+}
+
+var (
+	_ encoding.BinaryMarshaler   = (*Session)(nil)
+	_ encoding.BinaryAppender    = (*Session)(nil)
+	_ encoding.BinaryUnmarshaler = (*Session)(nil)
+)
+
+const (
+	sessionVersion = 1  // The version of the marshaled session state.
+	publicKeyLen   = 32 // The length, in bytes, of an X25519 public or private key.
+	chainKeyLen    = 32 // The length, in bytes, of a chain key.
+	messageKeyLen  = 32 // The length, in bytes, of a message key.
+
+	maxProtocolStateLen = 1024 // The maximum length, in bytes, of a marshaled protocol state.
+)
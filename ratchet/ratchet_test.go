@@ -0,0 +1,215 @@
+package ratchet_test
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/codahale/lockstitch-go"
+	"github.com/codahale/lockstitch-go/ratchet"
+)
+
+func TestSession(t *testing.T) {
+	t.Parallel()
+
+	alice, bob := newSessions(t)
+
+	// Exchange several rounds of messages, with each round triggering a DH ratchet step.
+	for round := range 5 {
+		for i := range 3 {
+			exchange(t, alice, bob, fmt.Sprintf("alice round %d message %d", round, i))
+		}
+
+		for i := range 3 {
+			exchange(t, bob, alice, fmt.Sprintf("bob round %d message %d", round, i))
+		}
+	}
+}
+
+func TestSession_ResponderCannotSendFirst(t *testing.T) {
+	t.Parallel()
+
+	_, bob := newSessions(t)
+
+	if _, err := bob.Encrypt([]byte("hello"), nil); !errors.Is(err, ratchet.ErrNoSendingChain) {
+		t.Errorf("Encrypt = %v, want = %v", err, ratchet.ErrNoSendingChain)
+	}
+}
+
+func TestSession_OutOfOrder(t *testing.T) {
+	t.Parallel()
+
+	alice, bob := newSessions(t)
+
+	var messages [][]byte
+	for i := range 5 {
+		messages = append(messages, encrypt(t, alice, fmt.Sprintf("message %d", i)))
+	}
+
+	// Bob replies, causing Alice to ratchet, and Alice sends more messages in a new chain.
+	decrypt(t, bob, messages[0], "message 0")
+	exchange(t, bob, alice, "reply")
+	late := encrypt(t, alice, "message 5")
+
+	for _, i := range []int{4, 2, 3, 1} {
+		decrypt(t, bob, messages[i], fmt.Sprintf("message %d", i))
+	}
+	decrypt(t, bob, late, "message 5")
+
+	// Skipped keys are only usable once.
+	if _, err := bob.Decrypt(messages[2], nil); !errors.Is(err, lockstitch.ErrInvalidCiphertext) {
+		t.Errorf("Decrypt(replayed message) = %v, want = %v", err, lockstitch.ErrInvalidCiphertext)
+	}
+}
+
+func TestSession_TooManySkipped(t *testing.T) {
+	t.Parallel()
+
+	alice, bob := newSessions(t)
+
+	for range ratchet.MaxSkip + 1 {
+		encrypt(t, alice, "skipped")
+	}
+
+	message := encrypt(t, alice, "too late")
+	if _, err := bob.Decrypt(message, nil); !errors.Is(err, ratchet.ErrTooManySkipped) {
+		t.Errorf("Decrypt = %v, want = %v", err, ratchet.ErrTooManySkipped)
+	}
+}
+
+func TestSession_Tampering(t *testing.T) {
+	t.Parallel()
+
+	alice, bob := newSessions(t)
+	message := encrypt(t, alice, "this is an example")
+
+	for i := range message {
+		modified := bytes.Clone(message)
+		modified[i] ^= 1
+		if _, err := bob.Decrypt(modified, nil); err == nil {
+			t.Errorf("Decrypt(message with byte %d modified) did not return an error", i)
+		}
+	}
+
+	if _, err := bob.Decrypt(message, []byte("wrong associated data")); !errors.Is(err, lockstitch.ErrInvalidCiphertext) {
+		t.Errorf("Decrypt(wrong associated data) = %v, want = %v", err, lockstitch.ErrInvalidCiphertext)
+	}
+
+	// Invalid messages do not change the session's state.
+	decrypt(t, bob, message, "this is an example")
+	exchange(t, bob, alice, "reply")
+}
+
+func TestSession_InitiatorWithoutReceivingChain(t *testing.T) {
+	t.Parallel()
+
+	bobKey, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	alice, err := ratchet.NewInitiator([]byte("this is a shared secret"), bobKey.PublicKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A message using the responder's initial public key does not trigger a DH ratchet step.
+	message := make([]byte, ratchet.Overhead)
+	copy(message, bobKey.PublicKey().Bytes())
+
+	if _, err := alice.Decrypt(message, nil); !errors.Is(err, lockstitch.ErrInvalidCiphertext) {
+		t.Errorf("Decrypt = %v, want = %v", err, lockstitch.ErrInvalidCiphertext)
+	}
+}
+
+func TestSession_MarshalBinary(t *testing.T) {
+	t.Parallel()
+
+	alice, bob := newSessions(t)
+	exchange(t, alice, bob, "first")
+	skipped := encrypt(t, alice, "skipped")
+	exchange(t, alice, bob, "second")
+	exchange(t, bob, alice, "reply")
+
+	for _, s := range []**ratchet.Session{&alice, &bob} {
+		state, err := (*s).MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var restored ratchet.Session
+		if err := restored.UnmarshalBinary(state); err != nil {
+			t.Fatal(err)
+		}
+		*s = &restored
+	}
+
+	decrypt(t, bob, skipped, "skipped")
+	exchange(t, alice, bob, "after restore")
+	exchange(t, bob, alice, "reply after restore")
+
+	var s ratchet.Session
+	if err := s.UnmarshalBinary([]byte("bad")); err == nil {
+		t.Error("UnmarshalBinary(invalid state) did not return an error")
+	}
+}
+
+func newSessions(t *testing.T) (alice, bob *ratchet.Session) {
+	t.Helper()
+
+	bobKey, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sharedSecret := []byte("this is a shared secret")
+
+	alice, err = ratchet.NewInitiator(sharedSecret, bobKey.PublicKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bob, err = ratchet.NewResponder(sharedSecret, bobKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return alice, bob
+}
+
+func exchange(t *testing.T, sender, receiver *ratchet.Session, plaintext string) {
+	t.Helper()
+
+	decrypt(t, receiver, encrypt(t, sender, plaintext), plaintext)
+}
+
+func encrypt(t *testing.T, s *ratchet.Session, plaintext string) []byte {
+	t.Helper()
+
+	message, err := s.Encrypt([]byte(plaintext), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := len(message), len(plaintext)+ratchet.Overhead; got != want {
+		t.Errorf("len(message) = %d, want = %d", got, want)
+	}
+
+	return message
+}
+
+func decrypt(t *testing.T, s *ratchet.Session, message []byte, want string) {
+	t.Helper()
+
+	got, err := s.Decrypt(message, nil)
+	if err != nil {
+		t.Fatalf("Decrypt(%q) = %v", want, err)
+	}
+
+	if string(got) != want {
+		t.Errorf("Decrypt = %q, want = %q", got, want)
+	}
+}
@@ -0,0 +1,177 @@
+package lockstitch
+
+import (
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// An Op is the operation code of a protocol operation, as appended to a protocol's transcript.
+//
+// Recordings include an OpRatchet operation after each Derive, Crypt, AuthCrypt, DeriveStream, and CryptStream
+// operation, when the protocol replaces its transcript with data derived from it. The protocol's internal expand
+// operation, which derives PRF data without changing the protocol's state, is not recorded.
+type Op byte
+
+const (
+	OpInit         Op = opInit         // Initializes a protocol with a domain separation string.
+	OpMix          Op = opMix          // Mixes a labeled input value into the protocol's state.
+	OpDerive       Op = opDerive       // Derives pseudorandom data from the protocol's transcript.
+	OpCrypt        Op = opCrypt        // Encrypts or decrypts a plaintext value.
+	OpAuthCrypt    Op = opAuthCrypt    // Opens or seals a plaintext value.
+	OpRatchet      Op = opRatchet      // Replaces the protocol's transcript with derived data after an output operation.
+	OpMixStream    Op = opMixStream    // Mixes a labeled input stream of unknown length into the protocol's state.
+	OpDeriveStream Op = opDeriveStream // Streams up to 64GiB of pseudorandom data derived from the protocol's transcript.
+	OpCryptStream  Op = opCryptStream  // Encrypts or decrypts a plaintext stream of unknown length.
+)
+
+// String returns the name of the operation.
+func (op Op) String() string {
+	switch op {
+	case OpInit:
+		return "Init"
+	case OpMix:
+		return "Mix"
+	case OpDerive:
+		return "Derive"
+	case OpCrypt:
+		return "Crypt"
+	case OpAuthCrypt:
+		return "AuthCrypt"
+	case opExpand:
+		return "Expand"
+	case OpRatchet:
+		return "Ratchet"
+	case OpMixStream:
+		return "MixStream"
+	case OpDeriveStream:
+		return "DeriveStream"
+	case OpCryptStream:
+		return "CryptStream"
+	default:
+		return fmt.Sprintf("Op(%#02x)", byte(op))
+	}
+}
+
+// FingerprintLen is the length, in bytes, of a transcript fingerprint.
+const FingerprintLen = maxExpandLen
+
+// An Operation is a record of a single operation performed on a protocol.
+type Operation struct {
+	// Op is the operation code.
+	Op Op
+
+	// Label is the operation's label. For Init operations, it is the domain separation string.
+	Label string
+
+	// InputLen is the length, in bytes, of the operation's input or, for Derive operations, its output.
+	InputLen uint64
+
+	// Input is the input of a Mix operation. It is nil unless the Recorder was configured to record inputs.
+	Input []byte
+
+	// Fingerprint is a value derived from the protocol's transcript after the operation. Two protocols which have the
+	// same fingerprint after an operation have the same state. It is not keyed, so it can be used to test guesses of
+	// any unrecorded input to the protocol.
+	Fingerprint [FingerprintLen]byte
+}
+
+// String returns a human-readable description of the operation.
+func (o Operation) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s(%q, %d bytes", o.Op, o.Label, o.InputLen)
+	if o.Input != nil {
+		fmt.Fprintf(&sb, ": %x", o.Input)
+	}
+	sb.WriteString(") -> ")
+	sb.WriteString(hex.EncodeToString(o.Fingerprint[:]))
+
+	return sb.String()
+}
+
+// A Recorder records the operations performed on a protocol, for debugging. Inputs to Mix operations are often secret
+// (e.g., keys), so a Recorder does not record them unless RecordInputs is true.
+//
+// Omitting an input does not hide it from an attacker who has the recording. Each fingerprint is derived from the
+// transcript without any secret key, so an unrecorded input can be checked offline by replaying the recorded operations
+// with a guessed value and comparing the fingerprints. High-entropy inputs like keys remain secret, but low-entropy
+// inputs like passwords can be brute-forced, so recordings of protocols with such inputs must be kept secret.
+//
+// A Recorder must only be used by a single protocol. Clones of a recorded protocol are not recorded.
+type Recorder struct {
+	// RecordInputs, if true, records the inputs of Mix operations.
+	RecordInputs bool
+
+	ops []Operation
+}
+
+// NewRecordedProtocol creates a new Protocol with the given domain separation string which records its operations in
+// the given Recorder.
+//
+// Recording an operation requires deriving a fingerprint from the protocol's transcript, which makes each operation
+// significantly slower. Recording should only be enabled for debugging.
+func NewRecordedProtocol(domain string, r *Recorder) *Protocol {
+	p := NewProtocol(domain)
+	p.rec = r
+	p.record(OpInit, domain, uint64(len(domain)), nil)
+
+	return p
+}
+
+// Operations returns the recorded operations, in the order in which they were performed.
+func (r *Recorder) Operations() []Operation {
+	return slices.Clone(r.ops)
+}
+
+// String returns a human-readable description of the recorded operations, one per line.
+func (r *Recorder) String() string {
+	var sb strings.Builder
+	for i, op := range r.ops {
+		fmt.Fprintf(&sb, "%d: %s\n", i, op)
+	}
+
+	return sb.String()
+}
+
+// Diff compares two sequences of recorded operations and returns a description of the first operation at which they
+// diverge. If the sequences are identical, Diff returns an empty string.
+func Diff(a, b []Operation) string {
+	for i := range max(len(a), len(b)) {
+		if i >= len(a) {
+			return fmt.Sprintf("operation %d: <none> != %s", i, b[i])
+		}
+
+		if i >= len(b) {
+			return fmt.Sprintf("operation %d: %s != <none>", i, a[i])
+		}
+
+		if !a[i].equal(&b[i]) {
+			return fmt.Sprintf("operation %d: %s != %s", i, a[i], b[i])
+		}
+	}
+
+	return ""
+}
+
+func (o *Operation) equal(other *Operation) bool {
+	return o.Op == other.Op && o.Label == other.Label && o.InputLen == other.InputLen &&
+		o.Fingerprint == other.Fingerprint
+}
+
+// record appends an operation to the protocol's Recorder, if it has one. It must only be called once the operation's
+// metadata and data has been appended to the transcript, as it reuses the protocol's metadata buffer.
+func (p *Protocol) record(op Op, label string, n uint64, input []byte) {
+	if p.rec == nil {
+		return
+	}
+
+	o := Operation{Op: op, Label: label, InputLen: n, Input: nil, Fingerprint: [FingerprintLen]byte{}}
+	if p.rec.RecordInputs && op == OpMix {
+		o.Input = append([]byte{}, input...)
+	}
+
+	var buf [expandBufLen]byte
+	copy(o.Fingerprint[:], p.expand("transcript fingerprint", buf[:0]))
+	p.rec.ops = append(p.rec.ops, o)
+}
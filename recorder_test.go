@@ -0,0 +1,115 @@
+package lockstitch_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/codahale/lockstitch-go"
+)
+
+func TestRecorder(t *testing.T) {
+	t.Parallel()
+
+	var r lockstitch.Recorder
+	p := lockstitch.NewRecordedProtocol("com.example.recorder", &r)
+	p.Mix("key", []byte("a secret key"))
+	p.Derive("output", nil, 8)
+	ciphertext := p.Seal("message", nil, []byte("hello"))
+
+	ops := r.Operations()
+
+	if got, want := len(ops), 6; got != want {
+		t.Fatalf("len(Operations()) = %d, want = %d:\n%s", got, want, &r)
+	}
+
+	for i, want := range []lockstitch.Op{
+		lockstitch.OpInit, lockstitch.OpMix, lockstitch.OpDerive, lockstitch.OpRatchet, lockstitch.OpAuthCrypt,
+		lockstitch.OpRatchet,
+	} {
+		if got := ops[i].Op; got != want {
+			t.Errorf("Operations()[%d].Op = %v, want = %v", i, got, want)
+		}
+	}
+
+	if got, want := ops[1].Label, "key"; got != want {
+		t.Errorf("Operations()[1].Label = %q, want = %q", got, want)
+	}
+
+	if got, want := ops[1].InputLen, uint64(12); got != want {
+		t.Errorf("Operations()[1].InputLen = %d, want = %d", got, want)
+	}
+
+	if got := ops[1].Input; got != nil {
+		t.Errorf("Operations()[1].Input = %x, want = nil", got)
+	}
+
+	if strings.Contains(r.String(), "a secret key") {
+		t.Errorf("String() = %q, contains secret input", r.String())
+	}
+
+	// Recording does not affect the protocol's state.
+	p2 := lockstitch.NewProtocol("com.example.recorder")
+	p2.Mix("key", []byte("a secret key"))
+	p2.Derive("output", nil, 8)
+	if got, want := p2.Seal("message", nil, []byte("hello")), ciphertext; !bytes.Equal(got, want) {
+		t.Errorf("Seal = %x, want = %x", got, want)
+	}
+}
+
+func TestRecorder_RecordInputs(t *testing.T) {
+	t.Parallel()
+
+	r := lockstitch.Recorder{RecordInputs: true}
+	p := lockstitch.NewRecordedProtocol("com.example.recorder", &r)
+	p.Mix("key", []byte("a secret key"))
+
+	if got, want := r.Operations()[1].Input, []byte("a secret key"); !bytes.Equal(got, want) {
+		t.Errorf("Operations()[1].Input = %x, want = %x", got, want)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	t.Parallel()
+
+	run := func(key string) []lockstitch.Operation {
+		var r lockstitch.Recorder
+		p := lockstitch.NewRecordedProtocol("com.example.recorder", &r)
+		p.Mix("nonce", []byte("a nonce"))
+		p.Mix("key", []byte(key))
+		p.Derive("output", nil, 8)
+		return r.Operations()
+	}
+
+	a, b := run("one key"), run("two key")
+
+	if got, want := lockstitch.Diff(a, a), ""; got != want {
+		t.Errorf("Diff(a, a) = %q, want = %q", got, want)
+	}
+
+	if got, want := lockstitch.Diff(a, b), "operation 2: Mix(\"key\", 7 bytes)"; !strings.HasPrefix(got, want) {
+		t.Errorf("Diff(a, b) = %q, want prefix %q", got, want)
+	}
+
+	if got, want := lockstitch.Diff(a, a[:2]), "operation 2: Mix(\"key\", 7 bytes)"; !strings.HasPrefix(got, want) ||
+		!strings.HasSuffix(got, "<none>") {
+		t.Errorf("Diff(a, a[:2]) = %q, want prefix %q", got, want)
+	}
+}
+
+func TestOp_String(t *testing.T) {
+	t.Parallel()
+
+	for op, want := range map[lockstitch.Op]string{
+		lockstitch.OpInit:        "Init",
+		lockstitch.OpAuthCrypt:   "AuthCrypt",
+		lockstitch.OpCryptStream: "CryptStream",
+		lockstitch.OpRatchet:     "Ratchet",
+		lockstitch.Op(0x06):      "Expand",
+		lockstitch.Op(0xff):      "Op(0xff)",
+	} {
+		if got := op.String(); got != want {
+			t.Errorf("Op(%d).String() = %q, want = %q", byte(op), got, want)
+		}
+	}
+}
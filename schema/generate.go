@@ -0,0 +1,260 @@
+package schema
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/format"
+	"go/token"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Generate returns Go source code for the given package which implements the schema's protocol.
+//
+// The generated code contains a sender type and a receiver type, named after the schema (e.g., MessageSender and
+// MessageReceiver), with constructors (e.g., NewMessageSender). Each subsequent step of the protocol is a distinct type
+// (e.g., MessageSenderStep1) whose only method performs that step's operation and returns the next step. Fixed-length
+// inputs and outputs are arrays. The steps of a sender or receiver share its state, and using a step more than once
+// panics.
+//
+// Generate returns ErrInvalidPackage if pkg is not a valid Go package name.
+func Generate(s *Schema, pkg string) ([]byte, error) {
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+
+	if !token.IsIdentifier(pkg) || pkg == "_" {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidPackage, pkg)
+	}
+
+	var b bytes.Buffer
+	b.WriteString("// Code generated by lockstitch-gen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&b, "package %s\n\n", pkg)
+	b.WriteString("import \"github.com/codahale/lockstitch-go\"\n")
+
+	for _, r := range []role{sender, receiver} {
+		g := generator{b: &b, s: s, role: r}
+		g.generate()
+	}
+
+	out, err := format.Source(b.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("schema: invalid generated code: %w", err)
+	}
+
+	return out, nil
+}
+
+// ErrInvalidPackage is returned when a package name is not a valid Go identifier.
+var ErrInvalidPackage = errors.New("schema: invalid package name")
+
+// A role is the side of a protocol for which code is generated.
+type role string
+
+const (
+	sender   role = "Sender"
+	receiver role = "Receiver"
+)
+
+type generator struct {
+	b    *bytes.Buffer
+	s    *Schema
+	role role
+}
+
+// generate writes the types of one side of the protocol.
+func (g *generator) generate() {
+	first, state := g.typeName(0), g.stateName()
+	g.comment("A %s performs the %s side of the %s protocol. Each step of the protocol must be used exactly once; "+
+		"using a step more than once panics.", first, g.side(), g.s.Name)
+	fmt.Fprintf(g.b, "type %s struct {\n\tst *%s\n}\n", first, state)
+
+	g.comment("New%s returns a new %s.", first, first)
+	fmt.Fprintf(g.b, "func New%s() %s {\n\treturn %s{st: &%s{p: lockstitch.NewProtocol(%s), step: 0}}\n}\n", first,
+		first, first, state, strconv.Quote(g.s.Domain))
+
+	for i := range g.s.Operations {
+		if i > 0 {
+			g.comment("A %s is step %d of the %s side of the %s protocol.", g.typeName(i), i, g.side(), g.s.Name)
+			fmt.Fprintf(g.b, "type %s struct {\n\tst *%s\n}\n", g.typeName(i), state)
+		}
+
+		g.method(i)
+	}
+
+	g.comment("A %s is the state shared by the steps of the %s side of the %s protocol.", state, g.side(), g.s.Name)
+	fmt.Fprintf(g.b, "type %s struct {\n\tp *lockstitch.Protocol\n\tstep int\n}\n", state)
+
+	g.comment("advance panics if the given step is not the next step of the protocol, then advances to the next step.")
+	fmt.Fprintf(g.b, "func (st *%s) advance(step int) {\n", state)
+	fmt.Fprintf(g.b, "\tif st == nil || st.step != step {\n\t\tpanic(%s)\n\t}\n\tst.step++\n}\n",
+		strconv.Quote(g.s.Name+" protocol step used more than once or after a failure"))
+}
+
+// method writes the method which performs operation i.
+//
+//nolint:funlen // it's a long switch
+func (g *generator) method(i int) {
+	op := g.s.Operations[i]
+	recv, label := g.typeName(i), strconv.Quote(op.Label)
+
+	// The final step returns no next step.
+	next, nextRet, nextErr := "", "", ""
+	if i < len(g.s.Operations)-1 {
+		next = g.typeName(i + 1)
+		nextRet, nextErr = ", "+next+"(s)", ", "+next+"{}"
+	}
+
+	// results returns the method's results: the given outputs, followed by the next step and, optionally, an error.
+	results := func(withErr bool, out ...string) string {
+		if next != "" {
+			out = append(out, next)
+		}
+		if withErr {
+			out = append(out, "error")
+		}
+
+		switch len(out) {
+		case 0:
+			return ""
+		case 1:
+			return " " + out[0]
+		default:
+			return " (" + strings.Join(out, ", ") + ")"
+		}
+	}
+
+	name := verb(op.Kind, g.role) + identifier(op.Label)
+
+	// advance checks that the step has not already been used.
+	advance := fmt.Sprintf("\ts.st.advance(%d)\n", i)
+
+	switch op.Kind {
+	case KindMix:
+		if op.Length > 0 {
+			g.comment("%s mixes the %d-byte input into the protocol's state with the label %s.", name, op.Length, label)
+			fmt.Fprintf(g.b, "func (s %s) %s(input [%d]byte)%s {\n", recv, name, op.Length, results(false))
+			g.b.WriteString(advance)
+			fmt.Fprintf(g.b, "\ts.st.p.Mix(%s, input[:])\n", label)
+		} else {
+			g.comment("%s mixes the input into the protocol's state with the label %s.", name, label)
+			fmt.Fprintf(g.b, "func (s %s) %s(input []byte)%s {\n", recv, name, results(false))
+			g.b.WriteString(advance)
+			fmt.Fprintf(g.b, "\ts.st.p.Mix(%s, input)\n", label)
+		}
+		if next != "" {
+			fmt.Fprintf(g.b, "\treturn %s(s)\n", next)
+		}
+	case KindDerive:
+		if op.Length > 0 {
+			g.comment("%s derives %d bytes of output from the protocol's state with the label %s.", name, op.Length,
+				label)
+			fmt.Fprintf(g.b, "func (s %s) %s()%s {\n", recv, name, results(false, fmt.Sprintf("[%d]byte", op.Length)))
+			g.b.WriteString(advance)
+			fmt.Fprintf(g.b, "\tvar out [%d]byte\n\ts.st.p.Derive(%s, out[:0], len(out))\n", op.Length, label)
+			fmt.Fprintf(g.b, "\treturn out%s\n", nextRet)
+		} else {
+			g.comment("%s derives n bytes of output from the protocol's state with the label %s and appends them to dst.",
+				name, label)
+			fmt.Fprintf(g.b, "func (s %s) %s(dst []byte, n int)%s {\n", recv, name, results(false, "[]byte"))
+			g.b.WriteString(advance)
+			fmt.Fprintf(g.b, "\treturn s.st.p.Derive(%s, dst, n)%s\n", label, nextRet)
+		}
+	case KindEncrypt:
+		in, method := "plaintext", "Encrypt"
+		if g.role == receiver {
+			in, method = "ciphertext", "Decrypt"
+		}
+		g.comment("%s %ss the %s with the label %s and appends the result to dst.", name, lower(method), in, label)
+		fmt.Fprintf(g.b, "func (s %s) %s(dst, %s []byte)%s {\n", recv, name, in, results(false, "[]byte"))
+		g.b.WriteString(advance)
+		fmt.Fprintf(g.b, "\treturn s.st.p.%s(%s, dst, %s)%s\n", method, label, in, nextRet)
+	case KindSeal:
+		if g.role == sender {
+			g.comment("%s seals the plaintext with the label %s and appends the result to dst.", name, label)
+			fmt.Fprintf(g.b, "func (s %s) %s(dst, plaintext []byte)%s {\n", recv, name, results(false, "[]byte"))
+			g.b.WriteString(advance)
+			fmt.Fprintf(g.b, "\treturn s.st.p.Seal(%s, dst, plaintext)%s\n", label, nextRet)
+		} else {
+			g.comment("%s opens the ciphertext with the label %s and appends the result to dst. If the ciphertext is "+
+				"not authentic, it returns lockstitch.ErrInvalidCiphertext and the protocol cannot be continued.", name,
+				label)
+			fmt.Fprintf(g.b, "func (s %s) %s(dst, ciphertext []byte)%s {\n", recv, name, results(true, "[]byte"))
+			g.b.WriteString(advance)
+			fmt.Fprintf(g.b, "\tif len(ciphertext) < lockstitch.TagLen {\n\t\treturn nil%s, "+
+				"lockstitch.ErrInvalidCiphertext\n\t}\n", nextErr)
+			fmt.Fprintf(g.b, "\tplaintext, err := s.st.p.Open(%s, dst, ciphertext)\n", label)
+			fmt.Fprintf(g.b, "\tif err != nil {\n\t\treturn nil%s, err\n\t}\n", nextErr)
+			fmt.Fprintf(g.b, "\treturn plaintext%s, nil\n", nextRet)
+		}
+	}
+
+	g.b.WriteString("}\n")
+}
+
+// comment writes a blank line followed by a doc comment, wrapped to fit within the maximum line length.
+func (g *generator) comment(format string, args ...any) {
+	g.b.WriteString("\n//")
+	n := len("//")
+	for _, word := range strings.Fields(fmt.Sprintf(format, args...)) {
+		if n+1+len(word) > maxLineLen {
+			g.b.WriteString("\n//")
+			n = len("//")
+		}
+		g.b.WriteString(" " + word)
+		n += 1 + len(word)
+	}
+	g.b.WriteString("\n")
+}
+
+// typeName returns the name of the type of step i.
+func (g *generator) typeName(i int) string {
+	if i == 0 {
+		return g.s.Name + string(g.role)
+	}
+
+	return g.s.Name + string(g.role) + "Step" + strconv.Itoa(i)
+}
+
+// stateName returns the name of the type of the state shared by the steps.
+func (g *generator) stateName() string {
+	return lower(g.s.Name) + string(g.role) + "State"
+}
+
+// side returns a lower-case description of the role.
+func (g *generator) side() string {
+	return lower(string(g.role))
+}
+
+// verb returns the prefix of the name of a method which performs an operation of the given kind.
+func verb(kind Kind, r role) string {
+	switch kind {
+	case KindMix:
+		return "Mix"
+	case KindDerive:
+		return "Derive"
+	case KindEncrypt:
+		if r == receiver {
+			return "Decrypt"
+		}
+		return "Encrypt"
+	case KindSeal:
+		if r == receiver {
+			return "Open"
+		}
+		return "Seal"
+	default:
+		panic("unknown kind: " + string(kind))
+	}
+}
+
+// lower returns s with its first rune converted to lower case.
+func lower(s string) string {
+	r, n := utf8.DecodeRuneInString(s)
+	return string(unicode.ToLower(r)) + s[n:]
+}
+
+const maxLineLen = 120 // The maximum length of a line of a generated doc comment.
@@ -0,0 +1,4 @@
+// Package example contains code generated from an example schema, for testing.
+package example
+
+//go:generate go run github.com/codahale/lockstitch-go/cmd/lockstitch-gen -pkg example -o example.go example.json
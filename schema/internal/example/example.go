@@ -0,0 +1,195 @@
+// Code generated by lockstitch-gen. DO NOT EDIT.
+
+package example
+
+import "github.com/codahale/lockstitch-go"
+
+// A MessageSender performs the sender side of the Message protocol. Each step of the protocol must be used exactly
+// once; using a step more than once panics.
+type MessageSender struct {
+	st *messageSenderState
+}
+
+// NewMessageSender returns a new MessageSender.
+func NewMessageSender() MessageSender {
+	return MessageSender{st: &messageSenderState{p: lockstitch.NewProtocol("com.example.message"), step: 0}}
+}
+
+// MixKey mixes the 32-byte input into the protocol's state with the label "key".
+func (s MessageSender) MixKey(input [32]byte) MessageSenderStep1 {
+	s.st.advance(0)
+	s.st.p.Mix("key", input[:])
+	return MessageSenderStep1(s)
+}
+
+// A MessageSenderStep1 is step 1 of the sender side of the Message protocol.
+type MessageSenderStep1 struct {
+	st *messageSenderState
+}
+
+// MixNonce mixes the 16-byte input into the protocol's state with the label "nonce".
+func (s MessageSenderStep1) MixNonce(input [16]byte) MessageSenderStep2 {
+	s.st.advance(1)
+	s.st.p.Mix("nonce", input[:])
+	return MessageSenderStep2(s)
+}
+
+// A MessageSenderStep2 is step 2 of the sender side of the Message protocol.
+type MessageSenderStep2 struct {
+	st *messageSenderState
+}
+
+// MixAssociatedData mixes the input into the protocol's state with the label "associated data".
+func (s MessageSenderStep2) MixAssociatedData(input []byte) MessageSenderStep3 {
+	s.st.advance(2)
+	s.st.p.Mix("associated data", input)
+	return MessageSenderStep3(s)
+}
+
+// A MessageSenderStep3 is step 3 of the sender side of the Message protocol.
+type MessageSenderStep3 struct {
+	st *messageSenderState
+}
+
+// EncryptHeader encrypts the plaintext with the label "header" and appends the result to dst.
+func (s MessageSenderStep3) EncryptHeader(dst, plaintext []byte) ([]byte, MessageSenderStep4) {
+	s.st.advance(3)
+	return s.st.p.Encrypt("header", dst, plaintext), MessageSenderStep4(s)
+}
+
+// A MessageSenderStep4 is step 4 of the sender side of the Message protocol.
+type MessageSenderStep4 struct {
+	st *messageSenderState
+}
+
+// SealMessage seals the plaintext with the label "message" and appends the result to dst.
+func (s MessageSenderStep4) SealMessage(dst, plaintext []byte) ([]byte, MessageSenderStep5) {
+	s.st.advance(4)
+	return s.st.p.Seal("message", dst, plaintext), MessageSenderStep5(s)
+}
+
+// A MessageSenderStep5 is step 5 of the sender side of the Message protocol.
+type MessageSenderStep5 struct {
+	st *messageSenderState
+}
+
+// DeriveSessionId derives 16 bytes of output from the protocol's state with the label "session id".
+func (s MessageSenderStep5) DeriveSessionId() [16]byte {
+	s.st.advance(5)
+	var out [16]byte
+	s.st.p.Derive("session id", out[:0], len(out))
+	return out
+}
+
+// A messageSenderState is the state shared by the steps of the sender side of the Message protocol.
+type messageSenderState struct {
+	p    *lockstitch.Protocol
+	step int
+}
+
+// advance panics if the given step is not the next step of the protocol, then advances to the next step.
+func (st *messageSenderState) advance(step int) {
+	if st == nil || st.step != step {
+		panic("Message protocol step used more than once or after a failure")
+	}
+	st.step++
+}
+
+// A MessageReceiver performs the receiver side of the Message protocol. Each step of the protocol must be used exactly
+// once; using a step more than once panics.
+type MessageReceiver struct {
+	st *messageReceiverState
+}
+
+// NewMessageReceiver returns a new MessageReceiver.
+func NewMessageReceiver() MessageReceiver {
+	return MessageReceiver{st: &messageReceiverState{p: lockstitch.NewProtocol("com.example.message"), step: 0}}
+}
+
+// MixKey mixes the 32-byte input into the protocol's state with the label "key".
+func (s MessageReceiver) MixKey(input [32]byte) MessageReceiverStep1 {
+	s.st.advance(0)
+	s.st.p.Mix("key", input[:])
+	return MessageReceiverStep1(s)
+}
+
+// A MessageReceiverStep1 is step 1 of the receiver side of the Message protocol.
+type MessageReceiverStep1 struct {
+	st *messageReceiverState
+}
+
+// MixNonce mixes the 16-byte input into the protocol's state with the label "nonce".
+func (s MessageReceiverStep1) MixNonce(input [16]byte) MessageReceiverStep2 {
+	s.st.advance(1)
+	s.st.p.Mix("nonce", input[:])
+	return MessageReceiverStep2(s)
+}
+
+// A MessageReceiverStep2 is step 2 of the receiver side of the Message protocol.
+type MessageReceiverStep2 struct {
+	st *messageReceiverState
+}
+
+// MixAssociatedData mixes the input into the protocol's state with the label "associated data".
+func (s MessageReceiverStep2) MixAssociatedData(input []byte) MessageReceiverStep3 {
+	s.st.advance(2)
+	s.st.p.Mix("associated data", input)
+	return MessageReceiverStep3(s)
+}
+
+// A MessageReceiverStep3 is step 3 of the receiver side of the Message protocol.
+type MessageReceiverStep3 struct {
+	st *messageReceiverState
+}
+
+// DecryptHeader decrypts the ciphertext with the label "header" and appends the result to dst.
+func (s MessageReceiverStep3) DecryptHeader(dst, ciphertext []byte) ([]byte, MessageReceiverStep4) {
+	s.st.advance(3)
+	return s.st.p.Decrypt("header", dst, ciphertext), MessageReceiverStep4(s)
+}
+
+// A MessageReceiverStep4 is step 4 of the receiver side of the Message protocol.
+type MessageReceiverStep4 struct {
+	st *messageReceiverState
+}
+
+// OpenMessage opens the ciphertext with the label "message" and appends the result to dst. If the ciphertext is not
+// authentic, it returns lockstitch.ErrInvalidCiphertext and the protocol cannot be continued.
+func (s MessageReceiverStep4) OpenMessage(dst, ciphertext []byte) ([]byte, MessageReceiverStep5, error) {
+	s.st.advance(4)
+	if len(ciphertext) < lockstitch.TagLen {
+		return nil, MessageReceiverStep5{}, lockstitch.ErrInvalidCiphertext
+	}
+	plaintext, err := s.st.p.Open("message", dst, ciphertext)
+	if err != nil {
+		return nil, MessageReceiverStep5{}, err
+	}
+	return plaintext, MessageReceiverStep5(s), nil
+}
+
+// A MessageReceiverStep5 is step 5 of the receiver side of the Message protocol.
+type MessageReceiverStep5 struct {
+	st *messageReceiverState
+}
+
+// DeriveSessionId derives 16 bytes of output from the protocol's state with the label "session id".
+func (s MessageReceiverStep5) DeriveSessionId() [16]byte {
+	s.st.advance(5)
+	var out [16]byte
+	s.st.p.Derive("session id", out[:0], len(out))
+	return out
+}
+
+// A messageReceiverState is the state shared by the steps of the receiver side of the Message protocol.
+type messageReceiverState struct {
+	p    *lockstitch.Protocol
+	step int
+}
+
+// advance panics if the given step is not the next step of the protocol, then advances to the next step.
+func (st *messageReceiverState) advance(step int) {
+	if st == nil || st.step != step {
+		panic("Message protocol step used more than once or after a failure")
+	}
+	st.step++
+}
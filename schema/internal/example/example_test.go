@@ -0,0 +1,117 @@
+package example_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/codahale/lockstitch-go"
+	"github.com/codahale/lockstitch-go/schema/internal/example"
+)
+
+func TestMessage(t *testing.T) {
+	t.Parallel()
+
+	key := [32]byte{1, 2, 3}
+	nonce := [16]byte{4, 5, 6}
+
+	sender := example.NewMessageSender().MixKey(key).MixNonce(nonce).MixAssociatedData([]byte("ad"))
+	header, step4 := sender.EncryptHeader(nil, []byte("header"))
+	message, step5 := step4.SealMessage(nil, []byte("message"))
+	senderID := step5.DeriveSessionId()
+
+	receiver := example.NewMessageReceiver().MixKey(key).MixNonce(nonce).MixAssociatedData([]byte("ad"))
+	gotHeader, rstep4 := receiver.DecryptHeader(nil, header)
+	gotMessage, rstep5, err := rstep4.OpenMessage(nil, message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	receiverID := rstep5.DeriveSessionId()
+
+	if got, want := gotHeader, []byte("header"); !bytes.Equal(got, want) {
+		t.Errorf("DecryptHeader = %q, want = %q", got, want)
+	}
+
+	if got, want := gotMessage, []byte("message"); !bytes.Equal(got, want) {
+		t.Errorf("OpenMessage = %q, want = %q", got, want)
+	}
+
+	if senderID != receiverID {
+		t.Errorf("DeriveSessionId = %x, want = %x", receiverID, senderID)
+	}
+
+	// The generated code is equivalent to performing the operations directly.
+	p := lockstitch.NewProtocol("com.example.message")
+	p.Mix("key", key[:])
+	p.Mix("nonce", nonce[:])
+	p.Mix("associated data", []byte("ad"))
+	if got, want := p.Encrypt("header", nil, []byte("header")), header; !bytes.Equal(got, want) {
+		t.Errorf("Encrypt = %x, want = %x", got, want)
+	}
+	if got, want := p.Seal("message", nil, []byte("message")), message; !bytes.Equal(got, want) {
+		t.Errorf("Seal = %x, want = %x", got, want)
+	}
+	if got, want := p.Derive("session id", nil, 16), senderID[:]; !bytes.Equal(got, want) {
+		t.Errorf("Derive = %x, want = %x", got, want)
+	}
+}
+
+func TestMessage_Inauthentic(t *testing.T) {
+	t.Parallel()
+
+	key := [32]byte{1, 2, 3}
+	nonce := [16]byte{4, 5, 6}
+
+	sender := example.NewMessageSender().MixKey(key).MixNonce(nonce).MixAssociatedData([]byte("ad"))
+	header, step4 := sender.EncryptHeader(nil, []byte("header"))
+	message, _ := step4.SealMessage(nil, []byte("message"))
+
+	receiver := example.NewMessageReceiver().MixKey(key).MixNonce(nonce).MixAssociatedData([]byte("other ad"))
+	_, rstep4 := receiver.DecryptHeader(nil, header)
+	if _, _, err := rstep4.OpenMessage(nil, message); !errors.Is(err, lockstitch.ErrInvalidCiphertext) {
+		t.Errorf("OpenMessage = %v, want = %v", err, lockstitch.ErrInvalidCiphertext)
+	}
+}
+
+func TestMessage_ShortCiphertext(t *testing.T) {
+	t.Parallel()
+
+	key := [32]byte{1, 2, 3}
+	nonce := [16]byte{4, 5, 6}
+
+	receiver := example.NewMessageReceiver().MixKey(key).MixNonce(nonce).MixAssociatedData([]byte("ad"))
+	_, rstep4 := receiver.DecryptHeader(nil, []byte("header"))
+	_, _, err := rstep4.OpenMessage(nil, make([]byte, lockstitch.TagLen-1))
+	if !errors.Is(err, lockstitch.ErrInvalidCiphertext) {
+		t.Errorf("OpenMessage = %v, want = %v", err, lockstitch.ErrInvalidCiphertext)
+	}
+}
+
+func TestMessage_Reuse(t *testing.T) {
+	t.Parallel()
+
+	key := [32]byte{1, 2, 3}
+	nonce := [16]byte{4, 5, 6}
+
+	sender := example.NewMessageSender()
+	step1 := sender.MixKey(key)
+	_ = step1.MixNonce(nonce)
+
+	for name, f := range map[string]func(){
+		"first step":  func() { sender.MixKey(key) },
+		"second step": func() { step1.MixNonce(nonce) },
+		"zero step":   func() { example.MessageReceiverStep5{}.DeriveSessionId() },
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			defer func() {
+				if recover() == nil {
+					t.Error("reusing a step did not panic")
+				}
+			}()
+
+			f()
+		})
+	}
+}
@@ -0,0 +1,142 @@
+// Package schema implements declarative schemas for Lockstitch protocols and generates Go code from them.
+//
+// A schema declares a protocol's domain separation string and an ordered list of operations, each with a label and,
+// optionally, a fixed length. From a schema, Generate produces a sender type and a receiver type for the protocol. Each
+// step of the protocol is a distinct type whose only method performs that step's operation and returns the type of the
+// next step, so operations can only be performed in the declared order, with the declared labels, and, for operations
+// with fixed lengths, with inputs and outputs of the declared lengths. The sender encrypts and seals where the receiver
+// decrypts and opens.
+//
+// Schemas are encoded as JSON:
+//
+//	{
+//	  "name": "Message",
+//	  "domain": "com.example.message",
+//	  "operations": [
+//	    {"op": "mix", "label": "key", "length": 32},
+//	    {"op": "mix", "label": "nonce", "length": 16},
+//	    {"op": "seal", "label": "message"}
+//	  ]
+//	}
+//
+// The cmd/lockstitch-gen command generates Go code from schema files.
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/token"
+	"strings"
+	"unicode"
+)
+
+// A Kind is a kind of operation.
+type Kind string
+
+const (
+	KindMix     Kind = "mix"     // Mixes an input into the protocol's state.
+	KindDerive  Kind = "derive"  // Derives output from the protocol's state.
+	KindEncrypt Kind = "encrypt" // Encrypts a plaintext (sender) or decrypts a ciphertext (receiver).
+	KindSeal    Kind = "seal"    // Seals a plaintext (sender) or opens a ciphertext (receiver).
+)
+
+// ErrInvalidSchema is returned when a schema is invalid.
+var ErrInvalidSchema = errors.New("schema: invalid schema")
+
+// A Schema declares a protocol as a domain separation string and an ordered list of operations.
+type Schema struct {
+	// Name is the name of the protocol. It must be an exported Go identifier, and is used as the prefix of the names of
+	// the generated types.
+	Name string `json:"name"`
+
+	// Domain is the protocol's domain separation string.
+	Domain string `json:"domain"`
+
+	// Operations are the protocol's operations, in order.
+	Operations []Operation `json:"operations"`
+}
+
+// An Operation is a single operation of a protocol.
+type Operation struct {
+	// Kind is the kind of operation.
+	Kind Kind `json:"op"`
+
+	// Label is the operation's label. It is also used to name the generated method, so it must contain at least one
+	// letter or digit and must start with a letter.
+	Label string `json:"label"`
+
+	// Length is the fixed length, in bytes, of a Mix operation's input or a Derive operation's output. If zero, the
+	// length is variable. Encrypt and Seal operations always have variable lengths.
+	Length int `json:"length,omitempty"`
+}
+
+// Parse parses and validates a JSON-encoded schema.
+func Parse(data []byte) (*Schema, error) {
+	var s Schema
+
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.DisallowUnknownFields()
+	if err := d.Decode(&s); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSchema, err)
+	}
+
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &s, nil
+}
+
+// Validate returns an error wrapping ErrInvalidSchema if the schema is invalid.
+func (s *Schema) Validate() error {
+	if !token.IsIdentifier(s.Name) || !token.IsExported(s.Name) {
+		return fmt.Errorf("%w: name %q is not an exported Go identifier", ErrInvalidSchema, s.Name)
+	}
+
+	if s.Domain == "" {
+		return fmt.Errorf("%w: empty domain", ErrInvalidSchema)
+	}
+
+	if len(s.Operations) == 0 {
+		return fmt.Errorf("%w: no operations", ErrInvalidSchema)
+	}
+
+	for i, op := range s.Operations {
+		switch op.Kind {
+		case KindMix, KindDerive:
+			if op.Length < 0 {
+				return fmt.Errorf("%w: operation %d: negative length", ErrInvalidSchema, i)
+			}
+		case KindEncrypt, KindSeal:
+			if op.Length != 0 {
+				return fmt.Errorf("%w: operation %d: %s operations cannot have fixed lengths", ErrInvalidSchema, i,
+					op.Kind)
+			}
+		default:
+			return fmt.Errorf("%w: operation %d: unknown kind %q", ErrInvalidSchema, i, op.Kind)
+		}
+
+		if name := identifier(op.Label); name == "" || !unicode.IsLetter(rune(name[0])) {
+			return fmt.Errorf("%w: operation %d: label %q cannot be used as a method name", ErrInvalidSchema, i,
+				op.Label)
+		}
+	}
+
+	return nil
+}
+
+// identifier converts a label to a camel-case identifier by capitalizing each run of letters and digits and removing
+// everything else.
+func identifier(label string) string {
+	var sb strings.Builder
+	for word := range strings.FieldsFuncSeq(label, func(r rune) bool {
+		return r > unicode.MaxASCII || (!unicode.IsLetter(r) && !unicode.IsDigit(r))
+	}) {
+		sb.WriteString(strings.ToUpper(word[:1]))
+		sb.WriteString(word[1:])
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,165 @@
+package schema_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/codahale/lockstitch-go/schema"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	s, err := schema.Parse([]byte(`{
+		"name": "Message",
+		"domain": "com.example.message",
+		"operations": [
+			{"op": "mix", "label": "key", "length": 32},
+			{"op": "seal", "label": "message"}
+		]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := s.Name, "Message"; got != want {
+		t.Errorf("Name = %q, want = %q", got, want)
+	}
+
+	if got, want := s.Domain, "com.example.message"; got != want {
+		t.Errorf("Domain = %q, want = %q", got, want)
+	}
+
+	if got, want := len(s.Operations), 2; got != want {
+		t.Fatalf("len(Operations) = %d, want = %d", got, want)
+	}
+
+	if got, want := s.Operations[0], (schema.Operation{Kind: schema.KindMix, Label: "key", Length: 32}); got != want {
+		t.Errorf("Operations[0] = %+v, want = %+v", got, want)
+	}
+
+	if got, want := s.Operations[1], (schema.Operation{Kind: schema.KindSeal, Label: "message", Length: 0}); got != want {
+		t.Errorf("Operations[1] = %+v, want = %+v", got, want)
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	t.Parallel()
+
+	for name, data := range map[string]string{
+		"malformed":       `{"name": "Message"`,
+		"unknown field":   `{"name": "Message", "domain": "d", "operations": [{"op": "mix", "label": "k"}], "x": 1}`,
+		"unexported name": `{"name": "message", "domain": "d", "operations": [{"op": "mix", "label": "k"}]}`,
+		"invalid name":    `{"name": "A Message", "domain": "d", "operations": [{"op": "mix", "label": "k"}]}`,
+		"empty domain":    `{"name": "Message", "domain": "", "operations": [{"op": "mix", "label": "k"}]}`,
+		"no operations":   `{"name": "Message", "domain": "d", "operations": []}`,
+		"unknown kind":    `{"name": "Message", "domain": "d", "operations": [{"op": "hash", "label": "k"}]}`,
+		"negative length": `{"name": "Message", "domain": "d", "operations": [{"op": "mix", "label": "k", "length": -1}]}`,
+		"fixed seal":      `{"name": "Message", "domain": "d", "operations": [{"op": "seal", "label": "k", "length": 1}]}`,
+		"empty label":     `{"name": "Message", "domain": "d", "operations": [{"op": "mix", "label": " "}]}`,
+		"numeric label":   `{"name": "Message", "domain": "d", "operations": [{"op": "mix", "label": "1st"}]}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if _, err := schema.Parse([]byte(data)); !errors.Is(err, schema.ErrInvalidSchema) {
+				t.Errorf("Parse = %v, want = %v", err, schema.ErrInvalidSchema)
+			}
+		})
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	t.Parallel()
+
+	// The generated code in internal/example must be up to date.
+	data, err := os.ReadFile("internal/example/example.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := schema.Parse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := schema.Generate(s, "example")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want, err := os.ReadFile("internal/example/example.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(got, want) {
+		t.Errorf("Generate = \n%s\nwant = \n%s\nrun go generate ./...", got, want)
+	}
+}
+
+func TestGenerate_InvalidPackage(t *testing.T) {
+	t.Parallel()
+
+	s := &schema.Schema{
+		Name:       "Hash",
+		Domain:     "com.example.hash",
+		Operations: []schema.Operation{{Kind: schema.KindMix, Label: "message", Length: 0}},
+	}
+
+	for _, pkg := range []string{"", "_", "func", "example/hash", "hash\nfunc init() {}"} {
+		if _, err := schema.Generate(s, pkg); !errors.Is(err, schema.ErrInvalidPackage) {
+			t.Errorf("Generate(%q) = %v, want = %v", pkg, err, schema.ErrInvalidPackage)
+		}
+	}
+}
+
+func TestGenerate_VariableDerive(t *testing.T) {
+	t.Parallel()
+
+	s := &schema.Schema{
+		Name:   "Hash",
+		Domain: "com.example.hash",
+		Operations: []schema.Operation{
+			{Kind: schema.KindMix, Label: "message", Length: 0},
+			{Kind: schema.KindDerive, Label: "digest", Length: 0},
+		},
+	}
+
+	got, err := schema.Generate(s, "hash")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		"func (s HashSender) MixMessage(input []byte) HashSenderStep1 {",
+		"func (s HashSenderStep1) DeriveDigest(dst []byte, n int) []byte {",
+		"func (s HashReceiverStep1) DeriveDigest(dst []byte, n int) []byte {",
+	} {
+		if !strings.Contains(string(got), want) {
+			t.Errorf("Generate = \n%s\nwant to contain %q", got, want)
+		}
+	}
+}
+
+func TestGenerate_UnicodeName(t *testing.T) {
+	t.Parallel()
+
+	s := &schema.Schema{
+		Name:       "Ärger",
+		Domain:     "com.example.ärger",
+		Operations: []schema.Operation{{Kind: schema.KindMix, Label: "message", Length: 0}},
+	}
+
+	got, err := schema.Generate(s, "example")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "type ärgerSenderState struct {"; !strings.Contains(string(got), want) {
+		t.Errorf("Generate = \n%s\nwant to contain %q", got, want)
+	}
+}
@@ -0,0 +1,8 @@
+package schnorr
+
+import "crypto/ecdh"
+
+// SignDeterministic signs the message using the given random data, for use in known-answer tests.
+func SignDeterministic(signer *ecdh.PrivateKey, message, random []byte, encrypted bool) ([]byte, error) {
+	return sign(signer, message, random, encrypted)
+}
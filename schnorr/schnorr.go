@@ -0,0 +1,139 @@
+// Package schnorr implements EdDSA-style Schnorr digital signatures over NIST P-256 using Lockstitch, as described in
+// the design document.
+//
+// Keys are crypto/ecdh P-256 keys. A signature consists of the compressed commitment point (33 bytes) followed by the
+// big-endian proof scalar (32 bytes), for a total of SignatureSize bytes.
+//
+// Commitment scalars are hedged: they are derived from a clone of the signing protocol after mixing in the signer's
+// private key and 32 bytes of random data. Signing remains secure if the random data is predictable, and a signer's
+// private key is not exposed by signing the same message twice.
+//
+// SignEncrypted and VerifyEncrypted implement a variant which encrypts the commitment point instead of mixing it into
+// the protocol. This makes it impossible to recover a signer's public key from a message and signature, at the cost of
+// batch verification.
+//
+// All arithmetic on the signer's private key and commitment scalars runs in constant time.
+package schnorr
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"errors"
+
+	"github.com/codahale/lockstitch-go"
+	"github.com/codahale/lockstitch-go/internal/p256"
+)
+
+// SignatureSize is the size, in bytes, of a signature.
+const SignatureSize = p256.CompressedPointSize + p256.ScalarSize
+
+// ErrUnsupportedCurve is returned when a key uses a curve other than P-256.
+var ErrUnsupportedCurve = errors.New("schnorr: unsupported curve")
+
+// GenerateKey generates a new, random P-256 private key.
+func GenerateKey() (*ecdh.PrivateKey, error) {
+	return ecdh.P256().GenerateKey(rand.Reader)
+}
+
+// Sign signs the message with the given private key and returns the signature.
+func Sign(signer *ecdh.PrivateKey, message []byte) ([]byte, error) {
+	return signRandom(signer, message, false)
+}
+
+// Verify returns true if sig is a valid signature of the message by the given public key.
+func Verify(signer *ecdh.PublicKey, message, sig []byte) bool {
+	return verify(signer, message, sig, false)
+}
+
+// SignEncrypted signs the message with the given private key and returns a signature whose commitment point is
+// encrypted. The signer's public key cannot be recovered from the message and signature.
+func SignEncrypted(signer *ecdh.PrivateKey, message []byte) ([]byte, error) {
+	return signRandom(signer, message, true)
+}
+
+// VerifyEncrypted returns true if sig is a valid signature of the message by the given public key, as created by
+// SignEncrypted.
+func VerifyEncrypted(signer *ecdh.PublicKey, message, sig []byte) bool {
+	return verify(signer, message, sig, true)
+}
+
+func signRandom(signer *ecdh.PrivateKey, message []byte, encrypted bool) ([]byte, error) {
+	var random [32]byte
+	if _, err := rand.Read(random[:]); err != nil {
+		return nil, err
+	}
+
+	return sign(signer, message, random[:], encrypted)
+}
+
+func sign(signer *ecdh.PrivateKey, message, random []byte, encrypted bool) ([]byte, error) {
+	if signer.Curve() != ecdh.P256() {
+		return nil, ErrUnsupportedCurve
+	}
+
+	p := newProtocol(signer.PublicKey(), message, encrypted)
+
+	// Derive a hedged commitment scalar from the protocol, the signer's private key, and the random data.
+	d := p256.ScalarFromPrivateKey(signer)
+	k := p256.CommitmentScalar(p, "signer private key", d, random)
+
+	// Calculate the commitment point and mix or encrypt it into the protocol.
+	sig := make([]byte, 0, SignatureSize)
+	sig = p256.ScalarBaseMult(k).Compressed(sig)
+	if encrypted {
+		sig = p.Encrypt("commitment", sig[:0], sig)
+	} else {
+		p.Mix("commitment", sig)
+	}
+
+	// Derive a challenge scalar and calculate the proof scalar.
+	r := p256.Challenge(p)
+	s := d.MulAdd(r, k)
+
+	return s.Append(sig), nil
+}
+
+func verify(signer *ecdh.PublicKey, message, sig []byte, encrypted bool) bool {
+	if signer.Curve() != ecdh.P256() || len(sig) != SignatureSize {
+		return false
+	}
+
+	p := newProtocol(signer, message, encrypted)
+
+	// Recover the commitment point and mix or decrypt it into the protocol.
+	commitment := sig[:p256.CompressedPointSize]
+	if encrypted {
+		commitment = p.Decrypt("commitment", nil, commitment)
+	} else {
+		p.Mix("commitment", commitment)
+	}
+
+	i, ok := p256.ParseCompressedPoint(commitment)
+	if !ok {
+		return false
+	}
+
+	s, ok := p256.DecodeScalar(sig[p256.CompressedPointSize:])
+	if !ok {
+		return false
+	}
+
+	// Derive a counterfactual challenge scalar and calculate the counterfactual commitment point.
+	r := p256.Challenge(p)
+	iP := p256.ScalarBaseMult(s).Add(p256.PointFromPublicKey(signer).ScalarMult(r).Neg())
+
+	return i.Equal(iP)
+}
+
+// newProtocol returns a protocol with the signer's public key and the message mixed in.
+func newProtocol(signer *ecdh.PublicKey, message []byte, encrypted bool) *lockstitch.Protocol {
+	domain := "lockstitch-go.schnorr"
+	if encrypted {
+		domain = "lockstitch-go.schnorr.encrypted"
+	}
+
+	p := lockstitch.NewProtocol(domain)
+	p.Mix("signer", signer.Bytes())
+	p.Mix("message", message)
+	return p
+}
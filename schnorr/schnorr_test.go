@@ -0,0 +1,192 @@
+package schnorr_test
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/codahale/lockstitch-go/schnorr"
+)
+
+func TestSign(t *testing.T) {
+	t.Parallel()
+
+	for _, v := range []struct {
+		name   string
+		sign   func(*ecdh.PrivateKey, []byte) ([]byte, error)
+		verify func(*ecdh.PublicKey, []byte, []byte) bool
+	}{
+		{"plain", schnorr.Sign, schnorr.Verify},
+		{"encrypted", schnorr.SignEncrypted, schnorr.VerifyEncrypted},
+	} {
+		t.Run(v.name, func(t *testing.T) {
+			t.Parallel()
+
+			signer := generateKey(t)
+			message := []byte("this is an example")
+
+			sig, err := v.sign(signer, message)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got, want := len(sig), schnorr.SignatureSize; got != want {
+				t.Errorf("len(sig) = %d, want = %d", got, want)
+			}
+
+			if !v.verify(signer.PublicKey(), message, sig) {
+				t.Error("Verify = false, want = true")
+			}
+
+			if v.verify(generateKey(t).PublicKey(), message, sig) {
+				t.Error("Verify(wrong signer) = true, want = false")
+			}
+
+			if v.verify(signer.PublicKey(), []byte("this is another example"), sig) {
+				t.Error("Verify(wrong message) = true, want = false")
+			}
+
+			if v.verify(signer.PublicKey(), message, sig[:len(sig)-1]) {
+				t.Error("Verify(short signature) = true, want = false")
+			}
+
+			for i := range sig {
+				modified := bytes.Clone(sig)
+				modified[i] ^= 1
+				if v.verify(signer.PublicKey(), message, modified) {
+					t.Errorf("Verify(signature with byte %d modified) = true, want = false", i)
+				}
+			}
+
+			// Signatures are hedged, so signing the same message twice produces different signatures.
+			sig2, err := v.sign(signer, message)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if bytes.Equal(sig, sig2) {
+				t.Error("Sign produced identical signatures")
+			}
+		})
+	}
+}
+
+func TestSign_Variants(t *testing.T) {
+	t.Parallel()
+
+	signer := generateKey(t)
+	message := []byte("this is an example")
+
+	sig, err := schnorr.Sign(signer, message)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if schnorr.VerifyEncrypted(signer.PublicKey(), message, sig) {
+		t.Error("VerifyEncrypted(plain signature) = true, want = false")
+	}
+
+	sig, err = schnorr.SignEncrypted(signer, message)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if schnorr.Verify(signer.PublicKey(), message, sig) {
+		t.Error("Verify(encrypted signature) = true, want = false")
+	}
+}
+
+func TestSign_UnsupportedCurve(t *testing.T) {
+	t.Parallel()
+
+	k, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := schnorr.Sign(k, nil); !errors.Is(err, schnorr.ErrUnsupportedCurve) {
+		t.Errorf("Sign(X25519 key) = %v, want = %v", err, schnorr.ErrUnsupportedCurve)
+	}
+
+	if schnorr.Verify(k.PublicKey(), nil, make([]byte, schnorr.SignatureSize)) {
+		t.Error("Verify(X25519 key) = true, want = false")
+	}
+}
+
+func TestVectors(t *testing.T) {
+	t.Parallel()
+
+	data, err := os.ReadFile("testdata/vectors.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var vectors []struct {
+		PrivateKey string `json:"private_key"`
+		PublicKey  string `json:"public_key"`
+		Random     string `json:"random"`
+		Message    string `json:"message"`
+		Encrypted  bool   `json:"encrypted"`
+		Signature  string `json:"signature"`
+	}
+	if err := json.Unmarshal(data, &vectors); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, v := range vectors {
+		signer, err := ecdh.P256().NewPrivateKey(mustDecodeHex(t, v.PrivateKey))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got, want := hex.EncodeToString(signer.PublicKey().Bytes()), v.PublicKey; got != want {
+			t.Errorf("vector %d: PublicKey = %v, want = %v", i, got, want)
+		}
+
+		message := mustDecodeHex(t, v.Message)
+		sig, err := schnorr.SignDeterministic(signer, message, mustDecodeHex(t, v.Random), v.Encrypted)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got, want := hex.EncodeToString(sig), v.Signature; got != want {
+			t.Errorf("vector %d: Sign = %v, want = %v", i, got, want)
+		}
+
+		verify := schnorr.Verify
+		if v.Encrypted {
+			verify = schnorr.VerifyEncrypted
+		}
+
+		if !verify(signer.PublicKey(), message, mustDecodeHex(t, v.Signature)) {
+			t.Errorf("vector %d: Verify = false, want = true", i)
+		}
+	}
+}
+
+func generateKey(tb testing.TB) *ecdh.PrivateKey {
+	tb.Helper()
+
+	k, err := schnorr.GenerateKey()
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	return k
+}
+
+func mustDecodeHex(tb testing.TB, s string) []byte {
+	tb.Helper()
+
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	return b
+}
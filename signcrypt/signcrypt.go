@@ -0,0 +1,152 @@
+// Package signcrypt implements an integrated signcryption scheme over NIST P-256 using Lockstitch, as described in the
+// design document.
+//
+// Signcryption combines an ECIES-style public-key encryption scheme with a Schnorr signature in a single protocol,
+// providing both confidentiality and strong authentication in the public key setting. Unlike Encrypt-then-Sign or
+// Sign-then-Encrypt, the signature covers the entire protocol transcript, including the ECDH shared secret and the
+// public keys of both the sender and the receiver.
+//
+// Keys are crypto/ecdh P-256 keys. A ciphertext consists of the uncompressed ephemeral public key (65 bytes), followed by
+// the encrypted plaintext, followed by the compressed commitment point (33 bytes) and the big-endian proof scalar (32
+// bytes), for a total of Overhead bytes more than the plaintext.
+//
+// Scalar and point arithmetic uses constant-time implementations, so neither the sender's private key nor the
+// commitment scalar leaks through timing side channels.
+package signcrypt
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"errors"
+
+	"github.com/codahale/lockstitch-go"
+	"github.com/codahale/lockstitch-go/internal/p256"
+)
+
+// Overhead is the difference, in bytes, between the length of a ciphertext and its plaintext.
+const Overhead = publicKeySize + p256.CompressedPointSize + p256.ScalarSize
+
+// ErrUnsupportedCurve is returned when a key uses a curve other than P-256.
+var ErrUnsupportedCurve = errors.New("signcrypt: unsupported curve")
+
+// GenerateKey generates a new, random P-256 private key.
+func GenerateKey() (*ecdh.PrivateKey, error) {
+	return ecdh.P256().GenerateKey(rand.Reader)
+}
+
+// Signcrypt encrypts and signs the plaintext for the given receiver's public key using the given sender's private key
+// and returns the ciphertext.
+func Signcrypt(sender *ecdh.PrivateKey, receiver *ecdh.PublicKey, plaintext []byte) ([]byte, error) {
+	if sender.Curve() != ecdh.P256() || receiver.Curve() != ecdh.P256() {
+		return nil, ErrUnsupportedCurve
+	}
+
+	// Generate an ephemeral key pair.
+	ephemeral, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	// Calculate the ECDH shared secret.
+	ss, err := ephemeral.ECDH(receiver)
+	if err != nil {
+		return nil, err
+	}
+
+	var random [32]byte
+	if _, err := rand.Read(random[:]); err != nil {
+		return nil, err
+	}
+
+	sc := newProtocol(receiver, sender.PublicKey(), ephemeral.PublicKey(), ss)
+
+	// Encrypt the plaintext, appending it to the ephemeral public key.
+	out := make([]byte, 0, len(plaintext)+Overhead)
+	out = append(out, ephemeral.PublicKey().Bytes()...)
+	out = sc.Encrypt("message", out, plaintext)
+
+	// Derive a hedged commitment scalar from the protocol, the sender's private key, and the random data.
+	d := p256.ScalarFromPrivateKey(sender)
+	k := p256.CommitmentScalar(sc, "sender private key", d, random[:])
+
+	// Calculate the commitment point and mix it into the protocol.
+	commitment := p256.ScalarBaseMult(k).Compressed(nil)
+	sc.Mix("commitment", commitment)
+
+	// Derive a challenge scalar and calculate the proof scalar.
+	r := p256.Challenge(sc)
+	s := d.MulAdd(r, k)
+
+	out = append(out, commitment...)
+	return s.Append(out), nil
+}
+
+// Unsigncrypt decrypts the ciphertext using the given receiver's private key and verifies that it was signcrypted by
+// the given sender. If the ciphertext is invalid or was not signcrypted by the sender for the receiver, it returns
+// lockstitch.ErrInvalidCiphertext.
+func Unsigncrypt(receiver *ecdh.PrivateKey, sender *ecdh.PublicKey, ciphertext []byte) ([]byte, error) {
+	if receiver.Curve() != ecdh.P256() || sender.Curve() != ecdh.P256() {
+		return nil, ErrUnsupportedCurve
+	}
+
+	if len(ciphertext) < Overhead {
+		return nil, lockstitch.ErrInvalidCiphertext
+	}
+
+	// Split the ciphertext into its components.
+	ephemeralPub := ciphertext[:publicKeySize]
+	encrypted := ciphertext[publicKeySize : len(ciphertext)-p256.CompressedPointSize-p256.ScalarSize]
+	commitment := ciphertext[len(ciphertext)-p256.CompressedPointSize-p256.ScalarSize : len(ciphertext)-p256.ScalarSize]
+	proof := ciphertext[len(ciphertext)-p256.ScalarSize:]
+
+	// Parse the ephemeral public key and calculate the ECDH shared secret.
+	ephemeral, err := ecdh.P256().NewPublicKey(ephemeralPub)
+	if err != nil {
+		return nil, lockstitch.ErrInvalidCiphertext
+	}
+
+	ss, err := receiver.ECDH(ephemeral)
+	if err != nil {
+		return nil, lockstitch.ErrInvalidCiphertext
+	}
+
+	i, ok := p256.ParseCompressedPoint(commitment)
+	if !ok {
+		return nil, lockstitch.ErrInvalidCiphertext
+	}
+
+	s, ok := p256.DecodeScalar(proof)
+	if !ok {
+		return nil, lockstitch.ErrInvalidCiphertext
+	}
+
+	sc := newProtocol(receiver.PublicKey(), sender, ephemeral, ss)
+
+	// Decrypt the plaintext and mix the commitment point into the protocol.
+	plaintext := sc.Decrypt("message", nil, encrypted)
+	sc.Mix("commitment", commitment)
+
+	// Derive a counterfactual challenge scalar and calculate the counterfactual commitment point.
+	r := p256.Challenge(sc)
+	iP := p256.ScalarBaseMult(s).Add(p256.PointFromPublicKey(sender).ScalarMult(r).Neg())
+
+	if !i.Equal(iP) {
+		clear(plaintext)
+		return nil, lockstitch.ErrInvalidCiphertext
+	}
+
+	return plaintext, nil
+}
+
+// newProtocol returns a protocol with the public keys and the ECDH shared secret mixed in.
+func newProtocol(receiver, sender, ephemeral *ecdh.PublicKey, ss []byte) *lockstitch.Protocol {
+	sc := lockstitch.NewProtocol("lockstitch-go.signcrypt")
+	sc.Mix("receiver", receiver.Bytes())
+	sc.Mix("sender", sender.Bytes())
+	sc.Mix("ephemeral", ephemeral.Bytes())
+	sc.Mix("ecdh", ss)
+	return sc
+}
+
+// publicKeySize is the size, in bytes, of an uncompressed P-256 public key.
+const publicKeySize = 1 + 2*p256.ScalarSize
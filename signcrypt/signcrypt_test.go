@@ -0,0 +1,161 @@
+package signcrypt_test
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"github.com/codahale/lockstitch-go"
+	"github.com/codahale/lockstitch-go/signcrypt"
+)
+
+func TestSigncrypt(t *testing.T) {
+	t.Parallel()
+
+	sender, receiver := generateKey(t), generateKey(t)
+	plaintext := []byte("this is an example")
+
+	ciphertext, err := signcrypt.Signcrypt(sender, receiver.PublicKey(), plaintext)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := len(ciphertext), len(plaintext)+signcrypt.Overhead; got != want {
+		t.Errorf("len(ciphertext) = %d, want = %d", got, want)
+	}
+
+	got, err := signcrypt.Unsigncrypt(receiver, sender.PublicKey(), ciphertext)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(got, plaintext) {
+		t.Errorf("Unsigncrypt = %x, want = %x", got, plaintext)
+	}
+
+	if _, err := signcrypt.Unsigncrypt(receiver, sender.PublicKey(), ciphertext[:signcrypt.Overhead-1]); !errors.Is(err, lockstitch.ErrInvalidCiphertext) {
+		t.Errorf("Unsigncrypt(short ciphertext) = %v, want = %v", err, lockstitch.ErrInvalidCiphertext)
+	}
+
+	for i := range ciphertext {
+		modified := bytes.Clone(ciphertext)
+		modified[i] ^= 1
+		if _, err := signcrypt.Unsigncrypt(receiver, sender.PublicKey(), modified); !errors.Is(err, lockstitch.ErrInvalidCiphertext) {
+			t.Errorf("Unsigncrypt(ciphertext with byte %d modified) = %v, want = %v", i, err, lockstitch.ErrInvalidCiphertext)
+		}
+	}
+}
+
+func TestUnsigncrypt_ForgedSender(t *testing.T) {
+	t.Parallel()
+
+	sender, receiver, adversary := generateKey(t), generateKey(t), generateKey(t)
+
+	// An adversary with their own key pair cannot create a ciphertext which appears to be from the sender.
+	forged, err := signcrypt.Signcrypt(adversary, receiver.PublicKey(), []byte("this is a forgery"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := signcrypt.Unsigncrypt(receiver, sender.PublicKey(), forged); !errors.Is(err, lockstitch.ErrInvalidCiphertext) {
+		t.Errorf("Unsigncrypt(forged sender) = %v, want = %v", err, lockstitch.ErrInvalidCiphertext)
+	}
+
+	// The receiver is also unable to forge a ciphertext from the sender using their own private key, which means the
+	// scheme is resistant to key compromise impersonation.
+	forged, err = signcrypt.Signcrypt(receiver, receiver.PublicKey(), []byte("this is a forgery"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := signcrypt.Unsigncrypt(receiver, sender.PublicKey(), forged); !errors.Is(err, lockstitch.ErrInvalidCiphertext) {
+		t.Errorf("Unsigncrypt(receiver forgery) = %v, want = %v", err, lockstitch.ErrInvalidCiphertext)
+	}
+}
+
+func TestUnsigncrypt_SwappedReceiver(t *testing.T) {
+	t.Parallel()
+
+	sender, receiver, other := generateKey(t), generateKey(t), generateKey(t)
+
+	ciphertext, err := signcrypt.Signcrypt(sender, receiver.PublicKey(), []byte("this is an example"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := signcrypt.Unsigncrypt(other, sender.PublicKey(), ciphertext); !errors.Is(err, lockstitch.ErrInvalidCiphertext) {
+		t.Errorf("Unsigncrypt(wrong receiver) = %v, want = %v", err, lockstitch.ErrInvalidCiphertext)
+	}
+
+	// A receiver which decrypts a message cannot re-encrypt it for another receiver and retain the sender's signature.
+	plaintext, err := signcrypt.Unsigncrypt(receiver, sender.PublicKey(), ciphertext)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	forwarded, err := signcrypt.Signcrypt(receiver, other.PublicKey(), plaintext)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Replace the forwarded signature with the sender's.
+	copy(forwarded[len(forwarded)-64:], ciphertext[len(ciphertext)-64:])
+
+	if _, err := signcrypt.Unsigncrypt(other, sender.PublicKey(), forwarded); !errors.Is(err, lockstitch.ErrInvalidCiphertext) {
+		t.Errorf("Unsigncrypt(forwarded) = %v, want = %v", err, lockstitch.ErrInvalidCiphertext)
+	}
+}
+
+func TestUnsigncrypt_TamperedCommitment(t *testing.T) {
+	t.Parallel()
+
+	sender, receiver := generateKey(t), generateKey(t)
+
+	a, err := signcrypt.Signcrypt(sender, receiver.PublicKey(), []byte("this is an example"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := signcrypt.Signcrypt(sender, receiver.PublicKey(), []byte("this is an example"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Replace the commitment point in one ciphertext with the valid commitment point from another.
+	const commitmentStart, commitmentEnd = 65 + 18, 65 + 18 + 33
+	copy(a[commitmentStart:commitmentEnd], b[commitmentStart:commitmentEnd])
+
+	if _, err := signcrypt.Unsigncrypt(receiver, sender.PublicKey(), a); !errors.Is(err, lockstitch.ErrInvalidCiphertext) {
+		t.Errorf("Unsigncrypt(tampered commitment) = %v, want = %v", err, lockstitch.ErrInvalidCiphertext)
+	}
+}
+
+func TestSigncrypt_UnsupportedCurve(t *testing.T) {
+	t.Parallel()
+
+	k, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := signcrypt.Signcrypt(k, generateKey(t).PublicKey(), nil); !errors.Is(err, signcrypt.ErrUnsupportedCurve) {
+		t.Errorf("Signcrypt(X25519 key) = %v, want = %v", err, signcrypt.ErrUnsupportedCurve)
+	}
+
+	if _, err := signcrypt.Unsigncrypt(generateKey(t), k.PublicKey(), nil); !errors.Is(err, signcrypt.ErrUnsupportedCurve) {
+		t.Errorf("Unsigncrypt(X25519 key) = %v, want = %v", err, signcrypt.ErrUnsupportedCurve)
+	}
+}
+
+func generateKey(tb testing.TB) *ecdh.PrivateKey {
+	tb.Helper()
+
+	k, err := signcrypt.GenerateKey()
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	return k
+}
@@ -0,0 +1,97 @@
+package lockstitch
+
+import (
+	"crypto/subtle"
+)
+
+// A SIV is a deterministic, nonce-misuse-resistant authenticated encryption scheme. Encrypting the same plaintext with
+// the same key and additional data always results in the same ciphertext, and no other information about the plaintext
+// is revealed. It is suitable for e.g., deterministic key wrapping or encrypted database indexes.
+//
+// Unlike the cipher.AEAD returned by NewAEAD, a SIV derives its tag from the full plaintext before encrypting it, and
+// uses the tag in place of a nonce. A SIV may be used with an additional data value which includes a nonce, in which
+// case reusing a nonce only reveals whether two plaintexts are equal.
+type SIV struct {
+	p *Protocol
+}
+
+// NewSIV returns a SIV which uses the given domain separation string and key. The key must be at least MinAEADKeySize
+// bytes long.
+func NewSIV(domain string, key []byte) (*SIV, error) {
+	if len(key) < MinAEADKeySize {
+		return nil, errInvalidAEADKey
+	}
+
+	p := NewProtocol(domain)
+	p.Mix("key", key)
+
+	return &SIV{p: p}, nil
+}
+
+// Seal encrypts and authenticates the plaintext and additional data values, appending the ciphertext and an
+// authentication tag of TagLen bytes to dst and returning the resulting slice.
+//
+// To reuse plaintext's storage for the encrypted output, use plaintext[:0] as dst. Otherwise, the remaining capacity of
+// dst must not overlap plaintext.
+func (s *SIV) Seal(dst, plaintext []byte, additionalData ...[]byte) []byte {
+	p := s.protocol(additionalData)
+
+	// Derive a tag from the full plaintext.
+	var buf [TagLen]byte
+	tag := sivTag(p, plaintext, buf[:0])
+
+	// Mix the tag into the protocol and encrypt the plaintext.
+	p.Mix("tag", tag)
+	ret := p.Encrypt("message", dst, plaintext)
+
+	return append(ret, tag...)
+}
+
+// Open decrypts the ciphertext and verifies its authentication tag with the additional data values. If the ciphertext
+// is authentic, it appends the plaintext to dst and returns the resulting slice; otherwise, ErrInvalidCiphertext is
+// returned.
+//
+// To reuse ciphertext's storage for the decrypted output, use ciphertext[:0] as dst. Otherwise, the remaining capacity
+// of dst must not overlap ciphertext.
+func (s *SIV) Open(dst, ciphertext []byte, additionalData ...[]byte) ([]byte, error) {
+	if len(ciphertext) < TagLen {
+		return nil, ErrInvalidCiphertext
+	}
+
+	// Split the ciphertext between ciphertext and tag.
+	ciphertext, tag := ciphertext[:len(ciphertext)-TagLen], ciphertext[len(ciphertext)-TagLen:]
+	p := s.protocol(additionalData)
+
+	// Mix the tag into a copy of the protocol and decrypt the ciphertext.
+	d := p.Clone()
+	d.Mix("tag", tag)
+	ret := d.Decrypt("message", dst, ciphertext)
+	plaintext := ret[len(dst):]
+
+	// Derive a counterfactual tag from the full plaintext and compare it with the tag in constant time. If they don't
+	// match, clear the unauthenticated plaintext.
+	var buf [TagLen]byte
+	if subtle.ConstantTimeCompare(tag, sivTag(p, plaintext, buf[:0])) == 0 {
+		clear(plaintext)
+		return nil, ErrInvalidCiphertext
+	}
+
+	return ret, nil
+}
+
+// protocol returns a clone of the keyed protocol with the additional data values mixed in.
+func (s *SIV) protocol(additionalData [][]byte) *Protocol {
+	p := s.p.Clone()
+	for _, ad := range additionalData {
+		p.Mix("ad", ad)
+	}
+
+	return p
+}
+
+// sivTag derives an authentication tag from a copy of the protocol and the plaintext.
+func sivTag(p *Protocol, plaintext, dst []byte) []byte {
+	a := p.Clone()
+	a.Mix("message", plaintext)
+	return a.Derive("tag", dst, TagLen)
+}
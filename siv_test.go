@@ -0,0 +1,122 @@
+package lockstitch_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/codahale/lockstitch-go"
+)
+
+func TestSIV_Vectors(t *testing.T) {
+	t.Parallel()
+
+	data, err := os.ReadFile("testdata/siv.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var vectors []struct {
+		Key            string   `json:"key"`
+		AdditionalData []string `json:"ad"`
+		Plaintext      string   `json:"plaintext"`
+		Ciphertext     string   `json:"ciphertext"`
+	}
+	if err := json.Unmarshal(data, &vectors); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, v := range vectors {
+		siv, err := lockstitch.NewSIV("com.example.siv", mustDecodeHex(t, v.Key))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ad := make([][]byte, len(v.AdditionalData))
+		for j, s := range v.AdditionalData {
+			ad[j] = mustDecodeHex(t, s)
+		}
+
+		plaintext := mustDecodeHex(t, v.Plaintext)
+		if got, want := hex.EncodeToString(siv.Seal(nil, plaintext, ad...)), v.Ciphertext; got != want {
+			t.Errorf("vector %d: Seal = %v, want = %v", i, got, want)
+		}
+
+		got, err := siv.Open(nil, mustDecodeHex(t, v.Ciphertext), ad...)
+		if err != nil {
+			t.Fatalf("vector %d: Open = %v", i, err)
+		}
+
+		if !bytes.Equal(got, plaintext) {
+			t.Errorf("vector %d: Open = %x, want = %x", i, got, plaintext)
+		}
+	}
+}
+
+func TestSIV_Deterministic(t *testing.T) {
+	t.Parallel()
+
+	siv, err := lockstitch.NewSIV("com.example.siv", []byte("yellow submarine"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nonce := []byte("reused nonce")
+	a := siv.Seal(nil, []byte("this is an example"), nonce)
+	b := siv.Seal(nil, []byte("this is an example"), nonce)
+	c := siv.Seal(nil, []byte("this is an exampla"), nonce)
+
+	if !bytes.Equal(a, b) {
+		t.Errorf("Seal(same plaintext) = %x, %x; want equal", a, b)
+	}
+
+	if bytes.Equal(a[:16], c[:16]) {
+		t.Errorf("Seal(plaintexts with identical prefixes) had identical prefixes: %x, %x", a, c)
+	}
+}
+
+func TestSIV_Inauthentic(t *testing.T) {
+	t.Parallel()
+
+	siv, err := lockstitch.NewSIV("com.example.siv", []byte("yellow submarine"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ad := []byte("additional data")
+	ciphertext := siv.Seal(nil, []byte("this is an example"), ad)
+
+	for i := range ciphertext {
+		modified := bytes.Clone(ciphertext)
+		modified[i] ^= 1
+		if _, err := siv.Open(nil, modified, ad); !errors.Is(err, lockstitch.ErrInvalidCiphertext) {
+			t.Errorf("Open(ciphertext with byte %d modified) = %v, want = %v", i, err, lockstitch.ErrInvalidCiphertext)
+		}
+	}
+
+	if _, err := siv.Open(nil, ciphertext, []byte("other data")); !errors.Is(err, lockstitch.ErrInvalidCiphertext) {
+		t.Errorf("Open(wrong ad) = %v, want = %v", err, lockstitch.ErrInvalidCiphertext)
+	}
+
+	if _, err := siv.Open(nil, ciphertext, ad, nil); !errors.Is(err, lockstitch.ErrInvalidCiphertext) {
+		t.Errorf("Open(extra ad) = %v, want = %v", err, lockstitch.ErrInvalidCiphertext)
+	}
+
+	if _, err := siv.Open(nil, ciphertext[:lockstitch.TagLen-1], ad); !errors.Is(err, lockstitch.ErrInvalidCiphertext) {
+		t.Errorf("Open(short ciphertext) = %v, want = %v", err, lockstitch.ErrInvalidCiphertext)
+	}
+}
+
+func mustDecodeHex(tb testing.TB, s string) []byte {
+	tb.Helper()
+
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	return b
+}
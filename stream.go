@@ -0,0 +1,211 @@
+package lockstitch
+
+import (
+	"errors"
+	"io"
+)
+
+// DefaultChunkSize is the recommended size, in bytes, of the plaintext chunks sealed by a SealWriter.
+const DefaultChunkSize = 64 * 1024
+
+// A SealWriter splits a plaintext into chunks and seals each chunk using a protocol, providing online authenticated
+// encryption of inputs of unknown length.
+//
+// Each chunk of the plaintext is sealed with a Seal operation, which ratchets the protocol's state, making each chunk
+// dependent on all previous chunks. Before the final chunk is sealed, a Mix operation marks it as the final chunk. As a
+// result, an OpenReader will detect any reordered, duplicated, or removed chunks, including a truncation of the stream
+// at a chunk boundary.
+type SealWriter struct {
+	p         *Protocol
+	label     string
+	w         io.Writer
+	buf       []byte
+	chunkSize int
+	err       error
+}
+
+// NewSealWriter returns a SealWriter which seals data written to it in chunks of chunkSize bytes using the given
+// protocol and label, and writes the sealed chunks to w. Each sealed chunk is TagLen bytes longer than its plaintext.
+//
+// The protocol must not be used until the returned SealWriter has been closed. NewSealWriter panics if chunkSize is not
+// positive.
+func NewSealWriter(p *Protocol, label string, w io.Writer, chunkSize int) *SealWriter {
+	if chunkSize <= 0 {
+		panic("invalid argument to NewSealWriter: chunkSize must be positive")
+	}
+
+	return &SealWriter{
+		p:         p,
+		label:     label,
+		w:         w,
+		buf:       make([]byte, 0, chunkSize+TagLen),
+		chunkSize: chunkSize,
+		err:       nil,
+	}
+}
+
+// Write buffers the given plaintext, sealing and writing a chunk each time a full chunk is followed by more plaintext.
+func (sw *SealWriter) Write(b []byte) (int, error) {
+	if sw.err != nil {
+		return 0, sw.err
+	}
+
+	n := 0
+	for len(b) > 0 {
+		// A full chunk followed by more plaintext is not the final chunk, so seal and write it.
+		if len(sw.buf) == sw.chunkSize {
+			if err := sw.flush(false); err != nil {
+				return n, err
+			}
+		}
+
+		c := copy(sw.buf[len(sw.buf):sw.chunkSize], b)
+		sw.buf = sw.buf[:len(sw.buf)+c]
+		b = b[c:]
+		n += c
+	}
+
+	return n, nil
+}
+
+// Close seals and writes the final chunk. It does not close the underlying writer. After Close returns, the protocol
+// may be used again.
+func (sw *SealWriter) Close() error {
+	if sw.err != nil {
+		if errors.Is(sw.err, errSealWriterClosed) {
+			return nil
+		}
+		return sw.err
+	}
+
+	if err := sw.flush(true); err != nil {
+		return err
+	}
+	sw.err = errSealWriterClosed
+
+	return nil
+}
+
+// flush seals the buffered plaintext as a chunk and writes it to the underlying writer.
+func (sw *SealWriter) flush(final bool) error {
+	if final {
+		sw.p.Mix(finalChunkLabel, nil)
+	}
+
+	chunk := sw.p.Seal(sw.label, sw.buf[:0], sw.buf)
+	sw.buf = sw.buf[:0]
+	if _, err := sw.w.Write(chunk); err != nil {
+		sw.err = err
+		return err
+	}
+
+	return nil
+}
+
+// An OpenReader reads chunks sealed by a SealWriter and opens them using a protocol. It only returns plaintext from
+// authenticated chunks.
+//
+// Because each chunk is authenticated individually, an OpenReader may return some plaintext before detecting that a
+// subsequent chunk has been modified. Callers must not treat the plaintext as complete until Read has returned io.EOF.
+type OpenReader struct {
+	p         *Protocol
+	label     string
+	r         io.Reader
+	buf       []byte
+	lookahead int
+	plaintext []byte
+	pending   []byte
+	err       error
+}
+
+// NewOpenReader returns an OpenReader which reads chunks sealed with the given chunk size from r and opens them using
+// the given protocol and label. The protocol and the chunk size must match those used to seal the chunks.
+//
+// The protocol must not be used until the returned OpenReader has returned io.EOF. NewOpenReader panics if chunkSize is
+// not positive.
+func NewOpenReader(p *Protocol, label string, r io.Reader, chunkSize int) *OpenReader {
+	if chunkSize <= 0 {
+		panic("invalid argument to NewOpenReader: chunkSize must be positive")
+	}
+
+	return &OpenReader{
+		p:         p,
+		label:     label,
+		r:         r,
+		buf:       make([]byte, chunkSize+TagLen+1),
+		lookahead: 0,
+		plaintext: make([]byte, 0, chunkSize),
+		pending:   nil,
+		err:       nil,
+	}
+}
+
+// Read reads authenticated plaintext into b. It returns io.EOF once the final chunk has been opened and all of its
+// plaintext has been read. If a chunk is not authentic, or if the stream of chunks was reordered or truncated, Read
+// returns ErrInvalidCiphertext.
+func (o *OpenReader) Read(b []byte) (int, error) {
+	for len(o.pending) == 0 {
+		if o.err != nil {
+			return 0, o.err
+		}
+		o.err = o.readChunk()
+	}
+
+	n := copy(b, o.pending)
+	o.pending = o.pending[n:]
+
+	return n, nil
+}
+
+// readChunk reads and opens the next chunk, using a single byte of lookahead to determine whether it's the final
+// chunk. It returns io.EOF after opening the final chunk.
+func (o *OpenReader) readChunk() error {
+	n, err := io.ReadFull(o.r, o.buf[o.lookahead:])
+	n += o.lookahead
+
+	final := false
+	switch {
+	case err == nil:
+		// A full chunk followed by another byte is not the final chunk.
+		n--
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		// Anything shorter is the final chunk.
+		final = true
+	default:
+		return err
+	}
+
+	if n < TagLen {
+		return ErrInvalidCiphertext
+	}
+
+	if final {
+		o.p.Mix(finalChunkLabel, nil)
+	}
+
+	plaintext, err := o.p.Open(o.label, o.plaintext[:0], o.buf[:n])
+	if err != nil {
+		return err
+	}
+	o.pending = plaintext
+
+	if final {
+		return io.EOF
+	}
+
+	// Carry the lookahead byte over to the next chunk.
+	o.buf[0] = o.buf[n]
+	o.lookahead = 1
+
+	return nil
+}
+
+var (
+	_ io.WriteCloser = (*SealWriter)(nil)
+	_ io.Reader      = (*OpenReader)(nil)
+)
+
+var errSealWriterClosed = errors.New("lockstitch: write to closed SealWriter")
+
+// finalChunkLabel is the label of the Mix operation which precedes the final chunk of a sealed stream.
+const finalChunkLabel = "final chunk"
@@ -0,0 +1,91 @@
+package lockstitch_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/codahale/lockstitch-go"
+)
+
+func TestSealWriter(t *testing.T) {
+	t.Parallel()
+
+	for _, n := range []int{0, 1, 99, 100, 101, 1000} {
+		plaintext := bytes.Repeat([]byte{0xAB}, n)
+
+		sealed, state := sealStream(t, "key", plaintext, 100)
+		if got, want := len(sealed), n+lockstitch.TagLen*max(1, (n+99)/100); got != want {
+			t.Errorf("len(sealed) = %d, want = %d", got, want)
+		}
+
+		opened, openState, err := openStream("key", sealed, 100)
+		if err != nil {
+			t.Fatalf("openStream(%d bytes) = %v", n, err)
+		}
+
+		if !bytes.Equal(opened, plaintext) {
+			t.Errorf("openStream(%d bytes) = %x, want = %x", n, opened, plaintext)
+		}
+
+		if !bytes.Equal(openState, state) {
+			t.Errorf("divergent posterior protocol states: %x != %x", openState, state)
+		}
+	}
+}
+
+func TestOpenReader_WrongKey(t *testing.T) {
+	t.Parallel()
+
+	sealed, _ := sealStream(t, "key", []byte("this is an example"), 8)
+	if _, _, err := openStream("other key", sealed, 8); !errors.Is(err, lockstitch.ErrInvalidCiphertext) {
+		t.Errorf("openStream(other key) = %v, want = %v", err, lockstitch.ErrInvalidCiphertext)
+	}
+}
+
+func TestOpenReader_WrongChunkSize(t *testing.T) {
+	t.Parallel()
+
+	sealed, _ := sealStream(t, "key", []byte("this is an example"), 8)
+	if _, _, err := openStream("key", sealed, 9); !errors.Is(err, lockstitch.ErrInvalidCiphertext) {
+		t.Errorf("openStream(chunkSize=9) = %v, want = %v", err, lockstitch.ErrInvalidCiphertext)
+	}
+}
+
+func sealStream(tb testing.TB, key string, plaintext []byte, chunkSize int) (sealed, state []byte) {
+	tb.Helper()
+
+	p := lockstitch.NewProtocol("sealed stream")
+	p.Mix("key", []byte(key))
+
+	buf := new(bytes.Buffer)
+	w := lockstitch.NewSealWriter(p, "message", buf, chunkSize)
+
+	// Write the plaintext in uneven pieces to exercise buffering.
+	for len(plaintext) > 0 {
+		n := min(7, len(plaintext))
+		if _, err := w.Write(plaintext[:n]); err != nil {
+			tb.Fatal(err)
+		}
+		plaintext = plaintext[n:]
+	}
+
+	if err := w.Close(); err != nil {
+		tb.Fatal(err)
+	}
+
+	return buf.Bytes(), p.Derive("state", nil, 8)
+}
+
+func openStream(key string, sealed []byte, chunkSize int) (plaintext, state []byte, err error) {
+	p := lockstitch.NewProtocol("sealed stream")
+	p.Mix("key", []byte(key))
+
+	plaintext, err = io.ReadAll(lockstitch.NewOpenReader(p, "message", bytes.NewReader(sealed), chunkSize))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return plaintext, p.Derive("state", nil, 8), nil
+}
@@ -0,0 +1,201 @@
+package lockstitch
+
+import (
+	"fmt"
+	"strconv"
+)
+
+const (
+	// AnyLen is the length of a Step whose input or output may be of any length. It is the zero value of Step.Len, so
+	// a Step which does not specify a length matches operations of any length.
+	AnyLen = 0
+
+	// EmptyLen is the length of a Step whose input or output must be empty.
+	EmptyLen = -1
+)
+
+// A Step is an operation expected by a StrictProtocol.
+type Step struct {
+	// Op is the expected operation code. Encrypt and Decrypt are OpCrypt, Seal and Open are OpAuthCrypt, and
+	// EncryptStream and DecryptStream are OpCryptStream.
+	Op Op
+
+	// Label is the expected label.
+	Label string
+
+	// Len is the expected length, in bytes, of a Mix operation's input, a Derive operation's output, or the plaintext of
+	// an encryption operation. If Len is AnyLen, any length is allowed; if Len is EmptyLen, only empty inputs and
+	// outputs are allowed. The lengths of streaming operations are not known in advance, so their steps must use AnyLen.
+	Len int
+}
+
+// String returns a human-readable description of the step.
+func (s Step) String() string {
+	switch s.Len {
+	case AnyLen:
+		return fmt.Sprintf("%s(%q)", s.Op, s.Label)
+	case EmptyLen:
+		return fmt.Sprintf("%s(%q, 0 bytes)", s.Op, s.Label)
+	}
+
+	return fmt.Sprintf("%s(%q, %d bytes)", s.Op, s.Label, s.Len)
+}
+
+// A SequenceError describes an operation performed on a StrictProtocol which deviates from its expected steps.
+type SequenceError struct {
+	// Index is the index of the operation in the protocol's sequence of steps.
+	Index int
+
+	// Got is the operation which was performed, or nil if the protocol was finished before all steps were performed.
+	Got *Step
+
+	// Want is the expected step, or nil if all steps had already been performed.
+	Want *Step
+}
+
+func (e *SequenceError) Error() string {
+	got, want := "end of protocol", "end of protocol"
+	if e.Got != nil {
+		got = e.Got.String()
+	}
+
+	if e.Want != nil {
+		want = e.Want.String()
+	}
+
+	return "lockstitch: step " + strconv.Itoa(e.Index) + ": got " + got + ", want " + want
+}
+
+// A StrictProtocol wraps a Protocol and checks that each operation performed on it matches an expected sequence of
+// steps. A StrictProtocol produces exactly the same outputs as a Protocol performing the same operations.
+//
+// StrictProtocol is intended to catch protocol mistakes (e.g., mismatched labels or operations performed out of order)
+// in tests and staging environments, so its checks are opt-in: they are only performed if the lockstitch_strict build
+// tag is set (e.g., go test -tags lockstitch_strict). Otherwise, a StrictProtocol performs no checks and has no
+// overhead beyond that of the wrapper.
+//
+// If an operation's kind, label, or length deviates from the next expected step, the operation panics with a
+// *SequenceError before modifying the protocol's state. If ReturnErrors is true, the operation is instead performed as
+// given and the deviation is returned by Err and Done. Subsequent operations are not checked.
+type StrictProtocol struct {
+	// ReturnErrors, if true, records deviations from the expected steps instead of panicking.
+	ReturnErrors bool
+
+	p     *Protocol
+	steps []Step
+	next  int
+	err   error
+}
+
+// NewStrictProtocol creates a new StrictProtocol with the given domain separation string which expects the given
+// sequence of steps.
+func NewStrictProtocol(domain string, steps ...Step) *StrictProtocol {
+	return NewStrictProtocolFrom(NewProtocol(domain), steps...)
+}
+
+// NewStrictProtocolFrom returns a StrictProtocol which wraps the given protocol and expects the given sequence of steps
+// to be performed on it. Operations performed on the protocol directly, instead of via the StrictProtocol, are not
+// checked.
+func NewStrictProtocolFrom(p *Protocol, steps ...Step) *StrictProtocol {
+	return &StrictProtocol{ReturnErrors: false, p: p, steps: steps, next: 0, err: nil}
+}
+
+// Err returns the first deviation from the expected steps, if ReturnErrors is true and a deviation has occurred.
+// Otherwise, it returns nil.
+func (s *StrictProtocol) Err() error {
+	return s.err
+}
+
+// Clone returns a copy of the StrictProtocol which wraps a clone of its protocol and expects the remainder of its
+// sequence of steps. See Protocol.Clone.
+func (s *StrictProtocol) Clone() *StrictProtocol {
+	return &StrictProtocol{ReturnErrors: s.ReturnErrors, p: s.p.Clone(), steps: s.steps, next: s.next, err: s.err}
+}
+
+// AppendBinary appends the binary representation of the wrapped protocol's state to b. The expected sequence of steps is
+// not included. See Protocol.AppendBinary.
+func (s *StrictProtocol) AppendBinary(b []byte) ([]byte, error) {
+	return s.p.AppendBinary(b)
+}
+
+// MarshalBinary returns the binary representation of the wrapped protocol's state. The expected sequence of steps is not
+// included. See Protocol.MarshalBinary.
+func (s *StrictProtocol) MarshalBinary() ([]byte, error) {
+	return s.p.MarshalBinary()
+}
+
+// Mix ratchets the protocol's state using the given label and input. See Protocol.Mix.
+func (s *StrictProtocol) Mix(label string, input []byte) {
+	s.check(OpMix, label, exactLen(len(input)))
+	s.p.Mix(label, input)
+}
+
+// MixWriter returns a MixWriter which mixes all data written to it into the protocol's state using the given label.
+// See Protocol.MixWriter.
+func (s *StrictProtocol) MixWriter(label string) *MixWriter {
+	s.check(OpMixStream, label, AnyLen)
+	return s.p.MixWriter(label)
+}
+
+// Derive generates pseudorandom output from the protocol's current state, the label, and the output length. See
+// Protocol.Derive.
+func (s *StrictProtocol) Derive(label string, dst []byte, n int) []byte {
+	s.check(OpDerive, label, exactLen(n))
+	return s.p.Derive(label, dst, n)
+}
+
+// DeriveReader returns a DeriveReader which generates up to 64GiB of pseudorandom output from the protocol's current
+// state and the label. See Protocol.DeriveReader.
+func (s *StrictProtocol) DeriveReader(label string) *DeriveReader {
+	s.check(OpDeriveStream, label, AnyLen)
+	return s.p.DeriveReader(label)
+}
+
+// Encrypt encrypts the plaintext using the protocol's current state as the key. See Protocol.Encrypt.
+func (s *StrictProtocol) Encrypt(label string, dst, plaintext []byte) []byte {
+	s.check(OpCrypt, label, exactLen(len(plaintext)))
+	return s.p.Encrypt(label, dst, plaintext)
+}
+
+// Decrypt decrypts the ciphertext using the protocol's current state as the key. See Protocol.Decrypt.
+func (s *StrictProtocol) Decrypt(label string, dst, ciphertext []byte) []byte {
+	s.check(OpCrypt, label, exactLen(len(ciphertext)))
+	return s.p.Decrypt(label, dst, ciphertext)
+}
+
+// EncryptStream returns a CryptStream which incrementally encrypts a plaintext of unknown length. See
+// Protocol.EncryptStream.
+func (s *StrictProtocol) EncryptStream(label string) *CryptStream {
+	s.check(OpCryptStream, label, AnyLen)
+	return s.p.EncryptStream(label)
+}
+
+// DecryptStream returns a CryptStream which incrementally decrypts a ciphertext of unknown length. See
+// Protocol.DecryptStream.
+func (s *StrictProtocol) DecryptStream(label string) *CryptStream {
+	s.check(OpCryptStream, label, AnyLen)
+	return s.p.DecryptStream(label)
+}
+
+// Seal encrypts the plaintext using the protocol's current state as the key and appends an authentication tag. See
+// Protocol.Seal.
+func (s *StrictProtocol) Seal(label string, dst, plaintext []byte) []byte {
+	s.check(OpAuthCrypt, label, exactLen(len(plaintext)))
+	return s.p.Seal(label, dst, plaintext)
+}
+
+// Open decrypts the ciphertext using the protocol's current state as the key and verifies its authentication tag. See
+// Protocol.Open.
+func (s *StrictProtocol) Open(label string, dst, ciphertext []byte) ([]byte, error) {
+	s.check(OpAuthCrypt, label, exactLen(max(len(ciphertext)-TagLen, 0)))
+	return s.p.Open(label, dst, ciphertext)
+}
+
+// exactLen returns the Step length of an operation on n bytes.
+func exactLen(n int) int {
+	if n == 0 {
+		return EmptyLen
+	}
+
+	return n
+}
@@ -0,0 +1,52 @@
+//go:build lockstitch_strict
+
+package lockstitch
+
+// Done returns the first deviation from the expected steps, if ReturnErrors is true and a deviation has occurred, or a
+// *SequenceError if any of the protocol's expected steps have not been performed.
+func (s *StrictProtocol) Done() error {
+	if s.err != nil {
+		return s.err
+	}
+
+	if s.next < len(s.steps) {
+		return &SequenceError{Index: s.next, Got: nil, Want: &s.steps[s.next]}
+	}
+
+	return nil
+}
+
+// check panics with a *SequenceError if the operation does not match the next expected step or, if ReturnErrors is
+// true, records it.
+func (s *StrictProtocol) check(op Op, label string, n int) {
+	if s.err != nil {
+		return
+	}
+
+	got := Step{Op: op, Label: label, Len: n}
+	if s.next >= len(s.steps) {
+		s.fail(&SequenceError{Index: s.next, Got: &got, Want: nil})
+		return
+	}
+
+	if want := &s.steps[s.next]; !want.matches(&got) {
+		s.fail(&SequenceError{Index: s.next, Got: &got, Want: want})
+		return
+	}
+
+	s.next++
+}
+
+// fail panics with the error or, if ReturnErrors is true, records it.
+func (s *StrictProtocol) fail(err *SequenceError) {
+	if !s.ReturnErrors {
+		panic(err)
+	}
+
+	s.err = err
+}
+
+// matches returns true if the operation matches the expected step.
+func (s *Step) matches(got *Step) bool {
+	return s.Op == got.Op && s.Label == got.Label && (s.Len == AnyLen || s.Len == got.Len)
+}
@@ -0,0 +1,144 @@
+//go:build lockstitch_strict
+
+package lockstitch_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/codahale/lockstitch-go"
+)
+
+func TestStrictProtocol_Deviations(t *testing.T) {
+	t.Parallel()
+
+	steps := []lockstitch.Step{
+		{Op: lockstitch.OpMix, Label: "key", Len: 16},
+		{Op: lockstitch.OpAuthCrypt, Label: "message", Len: lockstitch.AnyLen},
+	}
+
+	for name, tc := range map[string]struct {
+		f    func(s *lockstitch.StrictProtocol)
+		want string
+	}{
+		"wrong label": {
+			f:    func(s *lockstitch.StrictProtocol) { s.Mix("nonce", make([]byte, 16)) },
+			want: `lockstitch: step 0: got Mix("nonce", 16 bytes), want Mix("key", 16 bytes)`,
+		},
+		"wrong length": {
+			f:    func(s *lockstitch.StrictProtocol) { s.Mix("key", make([]byte, 32)) },
+			want: `lockstitch: step 0: got Mix("key", 32 bytes), want Mix("key", 16 bytes)`,
+		},
+		"wrong operation": {
+			f:    func(s *lockstitch.StrictProtocol) { s.Derive("key", nil, 16) },
+			want: `lockstitch: step 0: got Derive("key", 16 bytes), want Mix("key", 16 bytes)`,
+		},
+		"out of order": {
+			f:    func(s *lockstitch.StrictProtocol) { s.Seal("message", nil, nil) },
+			want: `lockstitch: step 0: got AuthCrypt("message", 0 bytes), want Mix("key", 16 bytes)`,
+		},
+		"too many operations": {
+			f: func(s *lockstitch.StrictProtocol) {
+				s.Mix("key", make([]byte, 16))
+				s.Seal("message", nil, nil)
+				s.Derive("extra", nil, 8)
+			},
+			want: `lockstitch: step 2: got Derive("extra", 8 bytes), want end of protocol`,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			defer func() {
+				err, ok := recover().(error)
+				if !ok {
+					t.Fatalf("did not panic with an error")
+				}
+
+				var seqErr *lockstitch.SequenceError
+				if !errors.As(err, &seqErr) {
+					t.Fatalf("panic = %v, want *SequenceError", err)
+				}
+
+				if got := err.Error(); got != tc.want {
+					t.Errorf("panic = %q, want = %q", got, tc.want)
+				}
+			}()
+
+			tc.f(lockstitch.NewStrictProtocol("com.example.strict", steps...))
+		})
+	}
+}
+
+func TestStrictProtocol_Done(t *testing.T) {
+	t.Parallel()
+
+	s := lockstitch.NewStrictProtocol("com.example.strict",
+		lockstitch.Step{Op: lockstitch.OpMix, Label: "key", Len: 16},
+		lockstitch.Step{Op: lockstitch.OpAuthCrypt, Label: "message", Len: lockstitch.AnyLen},
+	)
+	s.Mix("key", make([]byte, 16))
+
+	if got, want := s.Done().Error(), `lockstitch: step 1: got end of protocol, want AuthCrypt("message")`; got != want {
+		t.Errorf("Done = %q, want = %q", got, want)
+	}
+}
+
+func TestStrictProtocol_ReturnErrors(t *testing.T) {
+	t.Parallel()
+
+	s := lockstitch.NewStrictProtocol("com.example.strict",
+		lockstitch.Step{Op: lockstitch.OpMix, Label: "key", Len: 16},
+		lockstitch.Step{Op: lockstitch.OpDerive, Label: "output", Len: 8},
+	)
+	s.ReturnErrors = true
+	p := lockstitch.NewProtocol("com.example.strict")
+
+	s.Mix("nonce", make([]byte, 16))
+	p.Mix("nonce", make([]byte, 16))
+
+	// Deviating operations are performed as given.
+	if got, want := s.Derive("output", nil, 8), p.Derive("output", nil, 8); !bytes.Equal(got, want) {
+		t.Errorf("Derive = %x, want = %x", got, want)
+	}
+
+	want := `lockstitch: step 0: got Mix("nonce", 16 bytes), want Mix("key", 16 bytes)`
+	if err := s.Err(); err == nil || err.Error() != want {
+		t.Errorf("Err = %v, want = %q", err, want)
+	}
+
+	if err := s.Done(); err == nil || err.Error() != want {
+		t.Errorf("Done = %v, want = %q", err, want)
+	}
+}
+
+func TestStrictProtocol_Len(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		len   int
+		input []byte
+		ok    bool
+	}{
+		"zero value, empty":     {len: 0, input: nil, ok: true},
+		"zero value, non-empty": {len: 0, input: []byte("input"), ok: true},
+		"empty, empty":          {len: lockstitch.EmptyLen, input: nil, ok: true},
+		"empty, non-empty":      {len: lockstitch.EmptyLen, input: []byte("input"), ok: false},
+		"exact, empty":          {len: 5, input: nil, ok: false},
+		"exact, non-empty":      {len: 5, input: []byte("input"), ok: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			s := lockstitch.NewStrictProtocol("com.example.strict",
+				lockstitch.Step{Op: lockstitch.OpMix, Label: "input", Len: tc.len})
+			s.ReturnErrors = true
+			s.Mix("input", tc.input)
+
+			if got, want := s.Done() == nil, tc.ok; got != want {
+				t.Errorf("Done = %v, want ok = %v", s.Done(), want)
+			}
+		})
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !lockstitch_strict
+
+package lockstitch
+
+// Done returns nil, as checks are only performed if the lockstitch_strict build tag is set.
+func (s *StrictProtocol) Done() error {
+	return nil
+}
+
+// check does nothing, as checks are only performed if the lockstitch_strict build tag is set.
+func (s *StrictProtocol) check(Op, string, int) {}
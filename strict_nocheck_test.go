@@ -0,0 +1,20 @@
+//go:build !lockstitch_strict
+
+package lockstitch_test
+
+import (
+	"testing"
+
+	"github.com/codahale/lockstitch-go"
+)
+
+func TestStrictProtocol_NoCheck(t *testing.T) {
+	t.Parallel()
+
+	s := lockstitch.NewStrictProtocol("com.example.strict", lockstitch.Step{Op: lockstitch.OpMix, Label: "key", Len: 16})
+	s.Derive("not the key", nil, 8)
+
+	if err := s.Done(); err != nil {
+		t.Errorf("Done = %v, want = nil", err)
+	}
+}
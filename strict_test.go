@@ -0,0 +1,115 @@
+package lockstitch_test
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/codahale/lockstitch-go"
+)
+
+func TestStrictProtocol(t *testing.T) {
+	t.Parallel()
+
+	s := lockstitch.NewStrictProtocol("com.example.strict",
+		lockstitch.Step{Op: lockstitch.OpMix, Label: "key", Len: 16},
+		lockstitch.Step{Op: lockstitch.OpMix, Label: "nonce", Len: lockstitch.AnyLen},
+		lockstitch.Step{Op: lockstitch.OpMixStream, Label: "stream", Len: lockstitch.AnyLen},
+		lockstitch.Step{Op: lockstitch.OpCrypt, Label: "header", Len: 6},
+		lockstitch.Step{Op: lockstitch.OpAuthCrypt, Label: "message", Len: lockstitch.AnyLen},
+		lockstitch.Step{Op: lockstitch.OpDeriveStream, Label: "reader", Len: lockstitch.AnyLen},
+		lockstitch.Step{Op: lockstitch.OpDerive, Label: "state", Len: 8},
+	)
+	p := lockstitch.NewProtocol("com.example.strict")
+
+	s.Mix("key", []byte("yellow submarine"))
+	p.Mix("key", []byte("yellow submarine"))
+
+	s.Mix("nonce", []byte("a nonce"))
+	p.Mix("nonce", []byte("a nonce"))
+
+	for _, w := range []*lockstitch.MixWriter{s.MixWriter("stream"), p.MixWriter("stream")} {
+		if _, err := w.Write([]byte("streamed input")); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, want := s.Encrypt("header", nil, []byte("header")), p.Encrypt("header", nil, []byte("header"))
+	if !bytes.Equal(got, want) {
+		t.Errorf("Encrypt = %x, want = %x", got, want)
+	}
+
+	got, want = s.Seal("message", nil, []byte("message")), p.Seal("message", nil, []byte("message"))
+	if !bytes.Equal(got, want) {
+		t.Errorf("Seal = %x, want = %x", got, want)
+	}
+
+	var outputs [2][]byte
+	for i, r := range []*lockstitch.DeriveReader{s.DeriveReader("reader"), p.DeriveReader("reader")} {
+		outputs[i] = make([]byte, 32)
+		if _, err := io.ReadFull(r, outputs[i]); err != nil {
+			t.Fatal(err)
+		}
+		if err := r.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got, want := outputs[0], outputs[1]; !bytes.Equal(got, want) {
+		t.Errorf("DeriveReader = %x, want = %x", got, want)
+	}
+
+	if got, want := s.Derive("state", nil, 8), p.Derive("state", nil, 8); !bytes.Equal(got, want) {
+		t.Errorf("Derive = %x, want = %x", got, want)
+	}
+
+	if err := s.Done(); err != nil {
+		t.Errorf("Done = %v, want = nil", err)
+	}
+}
+
+func TestStrictProtocol_Wrap(t *testing.T) {
+	t.Parallel()
+
+	p := lockstitch.NewProtocol("com.example.strict")
+	p.Mix("key", []byte("yellow submarine"))
+
+	s := lockstitch.NewStrictProtocolFrom(p.Clone(),
+		lockstitch.Step{Op: lockstitch.OpMix, Label: "nonce", Len: lockstitch.AnyLen},
+		lockstitch.Step{Op: lockstitch.OpDerive, Label: "output", Len: 8},
+	)
+	s.Mix("nonce", []byte("a nonce"))
+	p.Mix("nonce", []byte("a nonce"))
+
+	got, err := s.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want, err := p.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(got, want) {
+		t.Errorf("MarshalBinary = %x, want = %x", got, want)
+	}
+
+	c := s.Clone()
+	if got, want := c.Derive("output", nil, 8), p.Derive("output", nil, 8); !bytes.Equal(got, want) {
+		t.Errorf("Clone().Derive = %x, want = %x", got, want)
+	}
+
+	if err := c.Done(); err != nil {
+		t.Errorf("Clone().Done = %v, want = nil", err)
+	}
+
+	// The clone's operations do not advance the original.
+	s.Derive("output", nil, 8)
+	if err := s.Done(); err != nil {
+		t.Errorf("Done = %v, want = nil", err)
+	}
+}
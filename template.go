@@ -0,0 +1,51 @@
+package lockstitch
+
+import (
+	"crypto/sha256"
+)
+
+// A Template is an immutable snapshot of a protocol's state, from which any number of identical protocols can be
+// created. It is useful for protocols which begin with the same operations (e.g., initializing a protocol and mixing in
+// a static key) and then diverge.
+//
+// Unlike a Protocol, a Template is safe for concurrent use by multiple goroutines.
+type Template struct {
+	state []byte
+}
+
+// NewTemplate returns a Template with a snapshot of the protocol's current state. The protocol is not modified.
+func NewTemplate(p *Protocol) *Template {
+	state, err := p.MarshalBinary()
+	if err != nil {
+		panic(err)
+	}
+
+	return &Template{state: state}
+}
+
+// New returns a new Protocol with the template's state.
+func (t *Template) New() *Protocol {
+	//nolint:exhaustruct // noCopy should not be initialized
+	p := &Protocol{
+		transcript: sha256.New(),
+		buf:        make([]byte, initialBufLen),
+		rec:        nil,
+	}
+	p.reset(t.state)
+
+	return p
+}
+
+// Reset replaces the protocol's state with the template's state. The protocol may be any Protocol, including a zero
+// value, and can be used as if it were returned by New. Reset does not allocate if the protocol has been used before,
+// which allows protocols to be pooled and reused.
+//
+// If the protocol was recording its operations, it stops recording.
+func (t *Template) Reset(p *Protocol) {
+	if p.transcript == nil {
+		p.transcript = sha256.New()
+	}
+
+	p.rec = nil
+	p.reset(t.state)
+}
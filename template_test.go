@@ -0,0 +1,90 @@
+package lockstitch_test
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+
+	"github.com/codahale/lockstitch-go"
+)
+
+func TestTemplate(t *testing.T) {
+	t.Parallel()
+
+	base := lockstitch.NewProtocol("com.example.template")
+	base.Mix("key", []byte("yellow submarine"))
+	tmpl := lockstitch.NewTemplate(base)
+
+	want := base.Clone()
+	want.Mix("nonce", []byte("a nonce"))
+	expected := want.Seal("message", nil, []byte("hello"))
+
+	p := tmpl.New()
+	p.Mix("nonce", []byte("a nonce"))
+	if got := p.Seal("message", nil, []byte("hello")); !bytes.Equal(got, expected) {
+		t.Errorf("New().Seal = %x, want = %x", got, expected)
+	}
+
+	// Resetting a used protocol discards its state.
+	tmpl.Reset(p)
+	p.Mix("nonce", []byte("a nonce"))
+	if got := p.Seal("message", nil, []byte("hello")); !bytes.Equal(got, expected) {
+		t.Errorf("Reset(used).Seal = %x, want = %x", got, expected)
+	}
+
+	// Resetting a zero value initializes it.
+	var zero lockstitch.Protocol
+	tmpl.Reset(&zero)
+	zero.Mix("nonce", []byte("a nonce"))
+	if got := zero.Seal("message", nil, []byte("hello")); !bytes.Equal(got, expected) {
+		t.Errorf("Reset(zero).Seal = %x, want = %x", got, expected)
+	}
+
+	// The template is unaffected by subsequent changes to the protocol it was created from.
+	base.Mix("another key", []byte("another key"))
+	p = tmpl.New()
+	p.Mix("nonce", []byte("a nonce"))
+	if got := p.Seal("message", nil, []byte("hello")); !bytes.Equal(got, expected) {
+		t.Errorf("New().Seal = %x, want = %x", got, expected)
+	}
+}
+
+func TestTemplate_Concurrent(t *testing.T) {
+	t.Parallel()
+
+	base := lockstitch.NewProtocol("com.example.template")
+	base.Mix("key", []byte("yellow submarine"))
+	tmpl := lockstitch.NewTemplate(base)
+	want := base.Derive("output", nil, 16)
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Go(func() {
+			p := tmpl.New()
+			for range 100 {
+				tmpl.Reset(p)
+				if got := p.Derive("output", nil, 16); !bytes.Equal(got, want) {
+					t.Errorf("Derive = %x, want = %x", got, want)
+					return
+				}
+			}
+		})
+	}
+	wg.Wait()
+}
+
+//nolint:paralleltest // uses testing.AllocsPerRun
+func TestTemplate_ResetAllocs(t *testing.T) {
+	base := lockstitch.NewProtocol("com.example.template")
+	base.Mix("key", []byte("yellow submarine"))
+	tmpl := lockstitch.NewTemplate(base)
+	p := tmpl.New()
+	input := []byte("a nonce")
+
+	if got, want := testing.AllocsPerRun(100, func() {
+		tmpl.Reset(p)
+		p.Mix("nonce", input)
+	}), 0.0; got != want {
+		t.Errorf("Reset allocs = %v, want = %v", got, want)
+	}
+}